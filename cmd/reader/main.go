@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rodrwan/themenu/internal/auth"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
 	"github.com/rodrwan/themenu/internal/cqrs/queries"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/reader"
@@ -31,12 +34,40 @@ func main() {
 	// Configurar los buses
 	qryBus := queries.NewQueryBus()
 
-	// Registrar los handlers
-	qryBus.Register("GetMenu", queries.NewGetMenuHandler(db))
-	qryBus.Register("GetUserOrders", queries.NewGetUserOrdersHandler(db))
+	// Middlewares del bus de consultas, del más externo al más interno
+	latency := pipeline.NewLatencyStats()
+	qryBus.Use(
+		pipeline.Logging(nil),
+		pipeline.Recovery(),
+		pipeline.Latency(latency),
+		pipeline.Validation(),
+	)
+
+	// Registrar los handlers y verificar que estén todos los que usa el servidor
+	err = errors.Join(
+		queries.Register[queries.GetMenuQuery, []queries.MenuItem](qryBus, queries.NewGetMenuHandler(db)),
+		queries.Register[queries.GetUserOrdersQuery, []queries.OrderDetail](qryBus, queries.NewGetUserOrdersHandler(db)),
+		queries.Register[queries.GetOrderQuery, queries.OrderDetail](qryBus, queries.NewGetOrderHandler(db)),
+		queries.Register[queries.ListOrdersQuery, []queries.OrderDetail](qryBus, queries.NewListOrdersHandler(db)),
+		queries.Register[queries.KitchenQueueQuery, []queries.KitchenTicket](qryBus, queries.NewKitchenQueueHandler(db)),
+		queries.Register[queries.GetUserNotificationsQuery, queries.NotificationPage](qryBus, queries.NewGetUserNotificationsHandler(db)),
+		queries.Register[queries.ListEventsQuery, queries.EventPage](qryBus, queries.NewListEventsHandler(db)),
+	)
+	if err == nil {
+		err = qryBus.Verify(reader.RequiredQueries...)
+	}
+	if err != nil {
+		log.Fatalf("Error al registrar los handlers de consultas: %v", err)
+	}
+
+	// Configurar la emisión y verificación de tokens JWT
+	tokens, err := auth.NewManagerFromEnv()
+	if err != nil {
+		log.Fatalf("Error en la configuración de JWT: %v", err)
+	}
 
 	// Crear y configurar el servidor
-	server := reader.NewServer(qryBus, db)
+	server := reader.NewServer(qryBus, db, tokens, latency)
 
 	// Iniciar el servidor
 	port := os.Getenv("PORT")
@@ -4,14 +4,34 @@ import (
 	"log"
 	"os"
 
+	"github.com/rodrwan/themenu/internal/auth"
 	"github.com/rodrwan/themenu/internal/cqrs"
 	"github.com/rodrwan/themenu/internal/web"
 )
 
 func main() {
 	eventBus := cqrs.NewEventBus()
-	apiClient := web.NewAPIClient("http://themenu-api:8080")
-	server := web.NewServer(eventBus, apiClient)
+
+	// El web llama al reader para las consultas y al writer para los comandos
+	readerURL := os.Getenv("READER_URL")
+	if readerURL == "" {
+		readerURL = "http://reader:8081"
+	}
+	writerURL := os.Getenv("WRITER_URL")
+	if writerURL == "" {
+		writerURL = "http://writer:8080"
+	}
+	apiClient := web.NewAPIClient(readerURL, writerURL)
+
+	// El web verifica los tokens de sesión con las mismas llaves que la API
+	tokens, err := auth.NewManagerFromEnv()
+	if err != nil {
+		log.Fatalf("Error en la configuración de JWT: %v", err)
+	}
+
+	server := web.NewServer(eventBus, apiClient, tokens, web.Config{
+		SecureCookies: os.Getenv("SESSION_COOKIE_SECURE") == "true",
+	})
 
 	// Iniciar el servidor
 	port := os.Getenv("PORT")
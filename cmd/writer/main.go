@@ -2,14 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rodrwan/themenu/internal/auth"
 	"github.com/rodrwan/themenu/internal/cqrs"
 	"github.com/rodrwan/themenu/internal/cqrs/commands"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
 	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/migrations"
 	"github.com/rodrwan/themenu/internal/writer"
+	"github.com/rodrwan/themenu/internal/writer/middleware"
 )
 
 func main() {
@@ -26,19 +32,69 @@ func main() {
 	}
 	defer pool.Close()
 
+	// El writer no inicia con un esquema desactualizado; las migraciones se
+	// aplican con cmd/migrate
+	migrator, err := migrations.New(pool)
+	if err != nil {
+		log.Fatalf("Error al leer las migraciones: %v", err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		log.Fatalf("Esquema de la base de datos desactualizado, ejecuta `go run ./cmd/migrate up`: %v", err)
+	}
+
 	// Crear el cliente de base de datos
-	db := database.New(pool)
+	db := database.NewStore(pool)
 
 	// Configurar los buses
 	eventBus := cqrs.NewEventBus()
 	cmdBus := commands.NewCommandBus()
 
-	// Registrar los handlers
-	cmdBus.Register("CreateOrder", commands.NewCreateOrderHandler(db, eventBus))
-	cmdBus.Register("UpdateOrderStatus", commands.NewUpdateOrderStatusHandler(db, eventBus))
+	// Middlewares del bus de comandos, del más externo al más interno
+	latency := pipeline.NewLatencyStats()
+	cmdBus.Use(
+		pipeline.Logging(nil),
+		pipeline.Recovery(),
+		pipeline.Latency(latency),
+		pipeline.Validation(),
+		pipeline.Retry(pipeline.DefaultRetryPolicy),
+	)
+
+	// Registrar los handlers y verificar que estén todos los que usa el servidor
+	err = errors.Join(
+		commands.Register[commands.CreateOrderCommand, commands.OrderResult](cmdBus, commands.NewCreateOrderHandler(db)),
+		commands.Register[commands.UpdateOrderStatusCommand, commands.StatusChange](cmdBus, commands.NewUpdateOrderStatusHandler(db)),
+		commands.Register[commands.MarkNotificationReadCommand, commands.NotificationsRead](cmdBus, commands.NewMarkNotificationReadHandler(db)),
+		commands.Register[commands.MarkAllNotificationsReadCommand, commands.NotificationsRead](cmdBus, commands.NewMarkAllNotificationsReadHandler(db)),
+	)
+	if err == nil {
+		err = cmdBus.Verify(writer.RequiredCommands...)
+	}
+	if err != nil {
+		log.Fatalf("Error al registrar los handlers de comandos: %v", err)
+	}
+
+	// Publicar en el EventBus los eventos escritos en el outbox
+	relay := cqrs.NewOutboxRelay(db, eventBus)
+	go relay.Run(ctx)
+
+	// Eliminar las Idempotency-Keys expiradas
+	go middleware.PurgeIdempotencyKeys(ctx, db, time.Hour)
+
+	// Configurar la emisión y verificación de tokens JWT
+	tokens, err := auth.NewManagerFromEnv()
+	if err != nil {
+		log.Fatalf("Error en la configuración de JWT: %v", err)
+	}
+	// El writer emite los tokens, así que necesita la llave privada de firma
+	if err := tokens.CheckSigningKey(); err != nil {
+		log.Fatalf("Error en la configuración de JWT: %v", err)
+	}
+
+	// URL pública del reader, usada en el header Location de las órdenes creadas
+	readerURL := os.Getenv("READER_URL")
 
 	// Crear y configurar el servidor
-	server := writer.NewServer(cmdBus, db, eventBus)
+	server := writer.NewServer(cmdBus, db, tokens, latency, readerURL)
 
 	// Iniciar el servidor
 	port := os.Getenv("PORT")
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritmos de firma soportados
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+const (
+	// DefaultIssuer es el emisor usado si no se configura JWT_ISSUER
+	DefaultIssuer = "themenu"
+	// DefaultAudience es la audiencia usada si no se configura JWT_AUDIENCE
+	DefaultAudience = "themenu-api"
+	// DefaultTTL es la duración de los tokens si no se configura JWT_TTL
+	DefaultTTL = 24 * time.Hour
+)
+
+// KeyConfig describe una llave del set de rotación. Las llaves HS256 usan
+// Secret; las RS256 usan un archivo PEM con la llave privada (para firmar) o
+// sólo la pública (para verificar).
+type KeyConfig struct {
+	KID            string `json:"kid"`
+	Alg            string `json:"alg"`
+	Secret         string `json:"secret,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	PublicKeyFile  string `json:"public_key_file,omitempty"`
+}
+
+// Config agrupa la configuración de los tokens JWT
+type Config struct {
+	Issuer   string
+	Audience string
+	TTL      time.Duration
+	// SigningKID es el kid de la llave usada para firmar tokens nuevos. Las
+	// demás llaves del set sólo se usan para verificar tokens existentes.
+	SigningKID string
+	Keys       []KeyConfig
+}
+
+// ConfigFromEnv lee la configuración desde variables de entorno:
+//
+//	JWT_KEYS_FILE    archivo JSON con el set de llaves ({"keys": [...]})
+//	JWT_KEYS         el mismo JSON, inline
+//	JWT_SECRET       atajo para una única llave HS256 con kid "default"
+//	JWT_SIGNING_KID  kid de la llave de firma (por defecto, la primera)
+//	JWT_ISSUER       emisor (iss)
+//	JWT_AUDIENCE     audiencia (aud)
+//	JWT_TTL          duración de los tokens, por ejemplo "12h"
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Issuer:     envOr("JWT_ISSUER", DefaultIssuer),
+		Audience:   envOr("JWT_AUDIENCE", DefaultAudience),
+		TTL:        DefaultTTL,
+		SigningKID: os.Getenv("JWT_SIGNING_KID"),
+	}
+
+	if ttl := os.Getenv("JWT_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return Config{}, fmt.Errorf("JWT_TTL inválido: %w", err)
+		}
+		cfg.TTL = d
+	}
+
+	var raw []byte
+	switch {
+	case os.Getenv("JWT_KEYS_FILE") != "":
+		data, err := os.ReadFile(os.Getenv("JWT_KEYS_FILE"))
+		if err != nil {
+			return Config{}, fmt.Errorf("error al leer JWT_KEYS_FILE: %w", err)
+		}
+		raw = data
+	case os.Getenv("JWT_KEYS") != "":
+		raw = []byte(os.Getenv("JWT_KEYS"))
+	case os.Getenv("JWT_SECRET") != "":
+		cfg.Keys = []KeyConfig{{KID: "default", Alg: AlgHS256, Secret: os.Getenv("JWT_SECRET")}}
+		return cfg, nil
+	default:
+		return Config{}, ErrNoKeys
+	}
+
+	var set struct {
+		Keys []KeyConfig `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return Config{}, fmt.Errorf("set de llaves JWT inválido: %w", err)
+	}
+	cfg.Keys = set.Keys
+	return cfg, nil
+}
+
+// key es una llave del set ya cargada en memoria
+type key struct {
+	kid    string
+	method jwt.SigningMethod
+	// sign es nil si la llave sólo sirve para verificar
+	sign   interface{}
+	verify interface{}
+}
+
+// loadKey convierte la configuración de una llave en material criptográfico
+func loadKey(cfg KeyConfig) (key, error) {
+	if cfg.KID == "" {
+		return key{}, fmt.Errorf("llave JWT sin kid")
+	}
+
+	switch cfg.Alg {
+	case AlgHS256:
+		if cfg.Secret == "" {
+			return key{}, fmt.Errorf("llave %s: secret requerido para HS256", cfg.KID)
+		}
+		secret := []byte(cfg.Secret)
+		return key{kid: cfg.KID, method: jwt.SigningMethodHS256, sign: secret, verify: secret}, nil
+
+	case AlgRS256:
+		k := key{kid: cfg.KID, method: jwt.SigningMethodRS256}
+		if cfg.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(cfg.PrivateKeyFile)
+			if err != nil {
+				return key{}, fmt.Errorf("llave %s: %w", cfg.KID, err)
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return key{}, fmt.Errorf("llave %s: %w", cfg.KID, err)
+			}
+			k.sign = private
+			k.verify = &private.PublicKey
+		}
+		if cfg.PublicKeyFile != "" {
+			pem, err := os.ReadFile(cfg.PublicKeyFile)
+			if err != nil {
+				return key{}, fmt.Errorf("llave %s: %w", cfg.KID, err)
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return key{}, fmt.Errorf("llave %s: %w", cfg.KID, err)
+			}
+			k.verify = public
+		}
+		if _, ok := k.verify.(*rsa.PublicKey); !ok {
+			return key{}, fmt.Errorf("llave %s: private_key_file o public_key_file requerido para RS256", cfg.KID)
+		}
+		return k, nil
+
+	default:
+		return key{}, fmt.Errorf("llave %s: algoritmo no soportado %q", cfg.KID, cfg.Alg)
+	}
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,13 @@
+package auth
+
+import "errors"
+
+var (
+	ErrNoKeys          = errors.New("no hay llaves JWT configuradas (JWT_KEYS_FILE, JWT_KEYS o JWT_SECRET)")
+	ErrSigningKey      = errors.New("la llave de firma JWT no existe o no tiene llave privada")
+	ErrInvalidToken    = errors.New("token inválido")
+	ErrUnknownKey      = errors.New("kid del token desconocido")
+	ErrInvalidSubject  = errors.New("el subject del token no es un ID de usuario válido")
+	ErrMissingToken    = errors.New("token no proporcionado")
+	ErrMalformedHeader = errors.New("formato de token inválido")
+)
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Claims son los claims incluidos en los tokens emitidos por TheMenu. El ID
+// del usuario va en el claim estándar "sub".
+type Claims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+}
+
+// Manager emite y verifica tokens JWT usando un set de llaves identificadas
+// por kid, lo que permite rotarlas sin invalidar los tokens vigentes
+type Manager struct {
+	issuer     string
+	audience   string
+	ttl        time.Duration
+	keys       map[string]key
+	signingKID string
+}
+
+// NewManager crea un Manager a partir de la configuración
+func NewManager(cfg Config) (*Manager, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	m := &Manager{
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		ttl:        cfg.TTL,
+		keys:       make(map[string]key, len(cfg.Keys)),
+		signingKID: cfg.SigningKID,
+	}
+
+	for _, kc := range cfg.Keys {
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := m.keys[k.kid]; exists {
+			return nil, fmt.Errorf("kid JWT duplicado: %s", k.kid)
+		}
+		m.keys[k.kid] = k
+	}
+
+	if m.signingKID == "" {
+		m.signingKID = cfg.Keys[0].KID
+	}
+	if _, ok := m.keys[m.signingKID]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSigningKey, m.signingKID)
+	}
+
+	return m, nil
+}
+
+// NewManagerFromEnv crea un Manager con la configuración de las variables de entorno
+func NewManagerFromEnv() (*Manager, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewManager(cfg)
+}
+
+// CheckSigningKey verifica que la llave de firma tenga llave privada. Los
+// servicios que emiten tokens lo llaman al iniciar para no fallar recién con
+// el primer token; los que sólo verifican pueden configurar llaves públicas.
+func (m *Manager) CheckSigningKey() error {
+	if k, ok := m.keys[m.signingKID]; !ok || k.sign == nil {
+		return fmt.Errorf("%w: %s", ErrSigningKey, m.signingKID)
+	}
+	return nil
+}
+
+// Issue emite un token firmado para el usuario y retorna su fecha de expiración
+func (m *Manager) Issue(userID uuid.UUID, email string) (string, time.Time, error) {
+	k, ok := m.keys[m.signingKID]
+	if !ok || k.sign == nil {
+		return "", time.Time{}, ErrSigningKey
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   userID.String(),
+			Issuer:    m.issuer,
+			Audience:  jwt.ClaimStrings{m.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Email: email,
+	}
+
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+
+	signed, err := token.SignedString(k.sign)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Verify valida la firma, la expiración, el emisor y la audiencia de un token
+// y retorna sus claims
+func (m *Manager) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, m.keyFunc,
+		jwt.WithIssuer(m.issuer),
+		jwt.WithAudience(m.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		if errors.Is(err, ErrUnknownKey) {
+			return nil, ErrUnknownKey
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+// UserID retorna el ID de usuario contenido en los claims
+func (c *Claims) UserID() (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return uuid.Nil, ErrInvalidSubject
+	}
+	return id, nil
+}
+
+// keyFunc elige la llave de verificación según el kid del token. El algoritmo
+// del token debe coincidir con el de la llave para evitar ataques de
+// confusión de algoritmo (por ejemplo, HS256 firmado con una llave pública).
+func (m *Manager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := m.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("algoritmo %s no corresponde a la llave %s", token.Method.Alg(), kid)
+	}
+	return k.verify, nil
+}
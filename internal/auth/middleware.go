@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// Middleware verifica el token JWT del header Authorization y guarda el ID del
+// usuario autenticado en el contexto (clave "user_id"). Es compartido por los
+// servidores reader y writer.
+func Middleware(tokens *Manager, db database.Querier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, err := bearerToken(c.GetHeader("Authorization"))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		claims, err := tokens.Verify(tokenString)
+		if err != nil {
+			log.Printf("Middleware: token rechazado: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
+			c.Abort()
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token inválido"})
+			c.Abort()
+			return
+		}
+
+		// Verificar que el usuario sigue existiendo
+		user, err := db.GetUser(c.Request.Context(), utils.ToPgUUID(userID))
+		if err != nil {
+			log.Printf("Middleware: error al obtener usuario: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+			c.Abort()
+			return
+		}
+
+		// Guardar el ID del usuario y los claims en el contexto
+		c.Set("user_id", user.ID)
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// bearerToken extrae el token de un header "Authorization: Bearer <token>"
+func bearerToken(header string) (string, error) {
+	if header == "" {
+		return "", ErrMissingToken
+	}
+
+	parts := strings.Split(header, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
+		return "", ErrMalformedHeader
+	}
+	return parts[1], nil
+}
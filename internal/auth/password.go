@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength es el largo mínimo de una contraseña
+const MinPasswordLength = 8
+
+// ErrInvalidCredentials indica que el email o la contraseña no son válidos.
+// No distingue un usuario inexistente de una contraseña incorrecta.
+var ErrInvalidCredentials = errors.New("credenciales inválidas")
+
+// dummyHash se compara cuando el usuario no existe o no tiene contraseña, de
+// modo que la respuesta tarde lo mismo que con una contraseña incorrecta
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("themenu-dummy-password"), bcrypt.DefaultCost)
+
+// HashPassword genera el hash bcrypt de una contraseña
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword compara una contraseña con su hash. Un hash vacío (usuario
+// inexistente o sin contraseña) nunca es válido. Retorna ErrInvalidCredentials
+// si no coinciden.
+func CheckPassword(hash, password string) error {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/database"
+)
+
+// Permisos definidos en la tabla permissions (ver seed.sql)
+const (
+	PermViewMenu          = "view_menu"
+	PermPlaceOrder        = "place_order"
+	PermUpdateOrderStatus = "update_order_status"
+	PermManageDishes      = "manage_dishes"
+	PermManageUsers       = "manage_users"
+	PermManageWebhooks    = "manage_webhooks"
+)
+
+// permissionsKey es la clave del contexto de gin donde se guardan los permisos
+// efectivos del usuario durante el request
+const permissionsKey = "permissions"
+
+// PermissionSet es el conjunto de permisos efectivos de un usuario
+type PermissionSet map[string]struct{}
+
+// Has indica si el conjunto contiene el permiso
+func (p PermissionSet) Has(permission string) bool {
+	_, ok := p[permission]
+	return ok
+}
+
+// Permissions retorna los permisos efectivos del usuario autenticado, es decir,
+// la unión de los permisos de todos sus roles. Se consultan una sola vez por
+// request y se guardan en el contexto para los siguientes chequeos.
+func Permissions(c *gin.Context, db database.Querier) (PermissionSet, error) {
+	if cached, ok := c.Get(permissionsKey); ok {
+		return cached.(PermissionSet), nil
+	}
+
+	userID, ok := c.Get("user_id")
+	if !ok {
+		return PermissionSet{}, nil
+	}
+	pgUserID, ok := userID.(pgtype.UUID)
+	if !ok {
+		return PermissionSet{}, nil
+	}
+
+	names, err := db.GetUserPermissions(c.Request.Context(), pgUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make(PermissionSet, len(names))
+	for _, name := range names {
+		permissions[name] = struct{}{}
+	}
+	c.Set(permissionsKey, permissions)
+	return permissions, nil
+}
+
+// RequirePermission retorna un middleware que responde 403 si el usuario
+// autenticado no tiene el permiso indicado. Debe usarse después de Middleware.
+func RequirePermission(db database.Querier, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissions, err := Permissions(c, db)
+		if err != nil {
+			log.Printf("Error al obtener los permisos del usuario: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar permisos"})
+			c.Abort()
+			return
+		}
+
+		if !permissions.Has(permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permiso insuficiente", "permission": permission})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAnyPermission retorna un middleware que responde 403 si el usuario
+// autenticado no tiene ninguno de los permisos indicados. Sirve para rutas
+// cuyo handler decide qué puede hacer cada usuario. Debe usarse después de
+// Middleware.
+func RequireAnyPermission(db database.Querier, required ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissions, err := Permissions(c, db)
+		if err != nil {
+			log.Printf("Error al obtener los permisos del usuario: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar permisos"})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range required {
+			if permissions.Has(permission) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permiso insuficiente", "permissions": required})
+		c.Abort()
+	}
+}
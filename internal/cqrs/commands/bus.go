@@ -1,48 +1,127 @@
 package commands
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 
-// CommandBus implementa el bus de comandos
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
+)
+
+// handlerEntry guarda un handler registrado junto con el tipo de su resultado
+type handlerEntry struct {
+	resultType reflect.Type
+	handle     func(ctx context.Context, command any) (any, error)
+}
+
+// CommandBus implementa el bus de comandos. Los handlers se registran por el
+// tipo Go del comando, por lo que no hay nombres que mantener sincronizados.
 type CommandBus struct {
-	handlers map[string]CommandHandler
-	mu       sync.RWMutex
+	handlers    map[reflect.Type]handlerEntry
+	middlewares []pipeline.Middleware
+	mu          sync.RWMutex
 }
 
 // NewCommandBus crea una nueva instancia del CommandBus
 func NewCommandBus() *CommandBus {
 	return &CommandBus{
-		handlers: make(map[string]CommandHandler),
+		handlers: make(map[reflect.Type]handlerEntry),
+	}
+}
+
+// Register registra el handler para los comandos de tipo C. Retorna
+// ErrDuplicateHandler si el tipo ya tenía un handler.
+func Register[C any, R any](bus *CommandBus, handler CommandHandler[C, R]) error {
+	commandType := reflect.TypeFor[C]()
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if _, exists := bus.handlers[commandType]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateHandler, commandType)
+	}
+
+	bus.handlers[commandType] = handlerEntry{
+		resultType: reflect.TypeFor[R](),
+		handle: func(ctx context.Context, command any) (any, error) {
+			return handler.Handle(ctx, command.(C))
+		},
 	}
+	return nil
 }
 
-// Register registra un handler para un tipo de comando
-func (b *CommandBus) Register(commandType string, handler CommandHandler) {
+// Dispatch envía un comando a su handler y retorna el resultado tipado
+func Dispatch[C any, R any](ctx context.Context, dispatcher CommandDispatcher, command C) (R, error) {
+	var zero R
+
+	result, err := dispatcher.dispatch(ctx, command)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := result.(R)
+	if !ok {
+		return zero, fmt.Errorf("%w: %T retorna %T", ErrResultType, command, result)
+	}
+	return typed, nil
+}
+
+// Use agrega middlewares a la cadena que envuelve a todos los handlers. Se
+// ejecutan en el orden en que se agregan, antes del handler.
+func (b *CommandBus) Use(middlewares ...pipeline.Middleware) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.handlers[commandType] = handler
+	b.middlewares = append(b.middlewares, middlewares...)
 }
 
-// Dispatch envía un comando a su handler correspondiente
-func (b *CommandBus) Dispatch(command Command) error {
+// dispatch implementa la interfaz CommandDispatcher
+func (b *CommandBus) dispatch(ctx context.Context, command any) (any, error) {
 	b.mu.RLock()
-	handler, exists := b.handlers[getCommandType(command)]
+	entry, exists := b.handlers[reflect.TypeOf(command)]
+	middlewares := b.middlewares
 	b.mu.RUnlock()
 
 	if !exists {
-		return ErrInvalidCommand
+		return nil, fmt.Errorf("%w: %T", ErrInvalidCommand, command)
 	}
 
-	return handler.Handle(command)
+	return pipeline.Chain(entry.handle, middlewares...)(ctx, command)
+}
+
+// Requirement describe un comando que debe tener un handler registrado con
+// un tipo de resultado determinado
+type Requirement struct {
+	command reflect.Type
+	result  reflect.Type
 }
 
-// getCommandType retorna el tipo de comando
-func getCommandType(command Command) string {
-	switch command.(type) {
-	case *CreateOrderCommand:
-		return "CreateOrder"
-	case *UpdateOrderStatusCommand:
-		return "UpdateOrderStatus"
-	default:
-		return "Unknown"
+// Require declara que el comando C debe tener un handler que retorne R
+func Require[C any, R any]() Requirement {
+	return Requirement{
+		command: reflect.TypeFor[C](),
+		result:  reflect.TypeFor[R](),
+	}
+}
+
+// Verify comprueba que todos los comandos requeridos tengan un handler con el
+// tipo de resultado esperado. Se usa al iniciar el servicio para detectar
+// registros faltantes antes de recibir requests.
+func (b *CommandBus) Verify(requirements ...Requirement) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var errs []error
+	for _, req := range requirements {
+		entry, exists := b.handlers[req.command]
+		if !exists {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrHandlerNotRegistered, req.command))
+			continue
+		}
+		if entry.resultType != req.result {
+			errs = append(errs, fmt.Errorf("%w: %s retorna %s, se esperaba %s", ErrResultType, req.command, entry.resultType, req.result))
+		}
 	}
+	return errors.Join(errs...)
 }
@@ -1,17 +1,20 @@
 package commands
 
-// Command representa una operación de escritura que modifica el estado
-type Command interface {
-	Execute() error
-}
+import (
+	"context"
+
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
+)
 
-// CommandHandler maneja la ejecución de un comando específico
-type CommandHandler interface {
-	Handle(command Command) error
+// CommandHandler maneja la ejecución de un comando de tipo C y retorna un
+// resultado de tipo R
+type CommandHandler[C any, R any] interface {
+	Handle(ctx context.Context, command C) (R, error)
 }
 
-// CommandDispatcher es el bus de comandos que distribuye los comandos a sus handlers
+// CommandDispatcher es el bus de comandos que distribuye los comandos a sus
+// handlers. Se usa a través de Dispatch, que retorna el resultado tipado.
 type CommandDispatcher interface {
-	Dispatch(command Command) error
-	Register(commandType string, handler CommandHandler)
+	Use(middlewares ...pipeline.Middleware)
+	dispatch(ctx context.Context, command any) (any, error)
 }
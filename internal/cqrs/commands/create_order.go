@@ -2,8 +2,12 @@ package commands
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rodrwan/themenu/internal/cqrs"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/utils"
@@ -11,80 +15,108 @@ import (
 
 // CreateOrderCommand representa el comando para crear una nueva orden
 type CreateOrderCommand struct {
-	UserID   uuid.UUID
-	DishID   uuid.UUID
-	Queries  database.Querier
-	EventBus *cqrs.EventBus
+	UserID uuid.UUID      `validate:"required"`
+	Items  []OrderItem    `validate:"min=1,dive"`
+	Store  database.Store `validate:"-"`
 }
 
-// Execute implementa la interfaz Command
-func (c *CreateOrderCommand) Execute() error {
-	ctx := context.Background()
-
-	// Verificar si el usuario ya tiene una orden activa
-	userUUID := utils.ToPgUUID(c.UserID)
-	orders, err := c.Queries.GetOrdersByUserId(ctx, userUUID)
-	if err != nil {
-		return err
+// Execute crea la orden y la retorna con sus líneas
+func (c *CreateOrderCommand) Execute(ctx context.Context) (OrderResult, error) {
+	if len(c.Items) == 0 {
+		return OrderResult{}, ErrEmptyOrder
 	}
-
-	for _, order := range orders {
-		if order.Status != "served" && order.Status != "cancelled" {
-			return ErrOrderExists
+	for _, item := range c.Items {
+		if item.Quantity <= 0 {
+			return OrderResult{}, ErrInvalidItem
 		}
 	}
 
-	// Verificar si el plato existe
-	dishUUID := utils.ToPgUUID(c.DishID)
-	_, err = c.Queries.GetDish(ctx, dishUUID)
-	if err != nil {
-		return ErrDishNotFound
-	}
+	// La orden y su evento se escriben en la misma transacción
+	var result OrderResult
+	err := c.Store.ExecTx(ctx, func(q database.Querier) error {
+		// Verificar si el usuario ya tiene una orden activa
+		userUUID := utils.ToPgUUID(c.UserID)
+		orders, err := q.GetOrdersByUserId(ctx, userUUID)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if !OrderTransitions.IsFinal(order.Status) {
+				return ErrOrderExists
+			}
+		}
+
+		// Crear la orden. Si otro request creó una orden activa en paralelo
+		// el índice idx_orders_active_user rechaza esta
+		order, err := q.CreateOrder(ctx, database.CreateOrderParams{
+			ID:     utils.ToPgUUID(uuid.New()),
+			UserID: userUUID,
+			Status: OrderStatusReceived,
+		})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_orders_active_user" {
+				return ErrOrderExists
+			}
+			return err
+		}
 
-	// Crear la orden
-	orderID := uuid.New()
-	_, err = c.Queries.CreateOrder(ctx, database.CreateOrderParams{
-		ID:     utils.ToPgUUID(orderID),
-		UserID: userUUID,
-		DishID: dishUUID,
-		Status: "received",
+		// Crear las líneas capturando el precio actual de cada plato
+		for _, item := range c.Items {
+			dish, err := q.GetDish(ctx, utils.ToPgUUID(item.DishID))
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return ErrDishNotFound
+				}
+				return err
+			}
+
+			notes := pgtype.Text{}
+			if item.Notes != "" {
+				notes = utils.ToPgText(item.Notes)
+			}
+
+			_, err = q.CreateOrderItem(ctx, database.CreateOrderItemParams{
+				ID:        utils.ToPgUUID(uuid.New()),
+				OrderID:   order.ID,
+				DishID:    dish.ID,
+				Quantity:  int32(item.Quantity),
+				UnitPrice: dish.Price,
+				Notes:     notes,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Registrar el evento de orden creada en el outbox
+		result, err = loadOrderResult(ctx, q, order)
+		if err != nil {
+			return err
+		}
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventOrderCreated, OrderStatusReceived, orderEventPayload(result))
 	})
 	if err != nil {
-		return err
+		return OrderResult{}, err
 	}
-
-	// Publicar evento de orden creada
-	c.EventBus.PublishEvent(cqrs.EventOrderCreated, "received", map[string]interface{}{
-		"order_id": orderID,
-		"user_id":  c.UserID,
-		"dish_id":  c.DishID,
-		"status":   "received",
-	})
-
-	return nil
+	return result, nil
 }
 
 // CreateOrderHandler maneja el comando CreateOrder
 type CreateOrderHandler struct {
-	db       database.Querier
-	eventBus *cqrs.EventBus
+	store database.Store
 }
 
 // NewCreateOrderHandler crea una nueva instancia del handler
-func NewCreateOrderHandler(db database.Querier, eventBus *cqrs.EventBus) *CreateOrderHandler {
+func NewCreateOrderHandler(store database.Store) *CreateOrderHandler {
 	return &CreateOrderHandler{
-		db:       db,
-		eventBus: eventBus,
+		store: store,
 	}
 }
 
 // Handle implementa la interfaz CommandHandler
-func (h *CreateOrderHandler) Handle(command Command) error {
-	cmd, ok := command.(*CreateOrderCommand)
-	if !ok {
-		return ErrInvalidCommand
-	}
-	cmd.Queries = h.db
-	cmd.EventBus = h.eventBus
-	return cmd.Execute()
+func (h *CreateOrderHandler) Handle(ctx context.Context, cmd CreateOrderCommand) (OrderResult, error) {
+	cmd.Store = h.store
+	return cmd.Execute(ctx)
 }
@@ -4,7 +4,16 @@ import "errors"
 
 var (
 	ErrInvalidCommand = errors.New("comando inválido")
-	ErrOrderExists    = errors.New("el usuario ya tiene una orden activa")
-	ErrDishNotFound   = errors.New("plato no encontrado")
-	ErrOrderNotFound  = errors.New("order not found")
+
+	ErrDuplicateHandler     = errors.New("el comando ya tiene un handler registrado")
+	ErrHandlerNotRegistered = errors.New("el comando no tiene un handler registrado")
+	ErrResultType           = errors.New("el handler retorna un tipo de resultado distinto al esperado")
+
+	ErrOrderExists   = errors.New("el usuario ya tiene una orden activa")
+	ErrDishNotFound  = errors.New("plato no encontrado")
+	ErrOrderNotFound = errors.New("order not found")
+	ErrEmptyOrder    = errors.New("la orden debe tener al menos un plato")
+	ErrInvalidItem   = errors.New("la cantidad de cada plato debe ser mayor a cero")
+
+	ErrNotificationNotFound = errors.New("notificación no encontrada")
 )
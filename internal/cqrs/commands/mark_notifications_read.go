@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// NotificationsRead describe las notificaciones marcadas como leídas
+type NotificationsRead struct {
+	NotificationIDs []string  `json:"notification_ids"`
+	ReadAt          time.Time `json:"read_at"`
+}
+
+// MarkNotificationReadCommand representa el comando para marcar como leída
+// una notificación del usuario
+type MarkNotificationReadCommand struct {
+	NotificationID uuid.UUID      `validate:"required"`
+	UserID         uuid.UUID      `validate:"required"`
+	Store          database.Store `validate:"-"`
+}
+
+// Execute marca la notificación como leída. Una notificación de otro usuario
+// se trata como inexistente.
+func (c *MarkNotificationReadCommand) Execute(ctx context.Context) (NotificationsRead, error) {
+	var result NotificationsRead
+	err := c.Store.ExecTx(ctx, func(q database.Querier) error {
+		notification, err := q.MarkNotificationRead(ctx, database.MarkNotificationReadParams{
+			ID:     utils.ToPgUUID(c.NotificationID),
+			UserID: utils.ToPgUUID(c.UserID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNotificationNotFound
+			}
+			return err
+		}
+
+		result = NotificationsRead{
+			NotificationIDs: []string{c.NotificationID.String()},
+			ReadAt:          notification.ReadAt.Time,
+		}
+		return enqueueNotificationsRead(ctx, q, c.UserID, result)
+	})
+	if err != nil {
+		return NotificationsRead{}, err
+	}
+	return result, nil
+}
+
+// MarkAllNotificationsReadCommand representa el comando para marcar como
+// leídas todas las notificaciones del usuario
+type MarkAllNotificationsReadCommand struct {
+	UserID uuid.UUID      `validate:"required"`
+	Store  database.Store `validate:"-"`
+}
+
+// Execute marca como leídas las notificaciones pendientes del usuario
+func (c *MarkAllNotificationsReadCommand) Execute(ctx context.Context) (NotificationsRead, error) {
+	result := NotificationsRead{NotificationIDs: []string{}}
+	err := c.Store.ExecTx(ctx, func(q database.Querier) error {
+		ids, err := q.MarkAllNotificationsRead(ctx, utils.ToPgUUID(c.UserID))
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, id := range ids {
+			result.NotificationIDs = append(result.NotificationIDs, utils.FromPgUUID(id).String())
+		}
+		result.ReadAt = time.Now()
+		return enqueueNotificationsRead(ctx, q, c.UserID, result)
+	})
+	if err != nil {
+		return NotificationsRead{}, err
+	}
+	return result, nil
+}
+
+// enqueueNotificationsRead registra el evento NotificationRead en el outbox,
+// para que las otras pestañas del usuario actualicen sus notificaciones
+func enqueueNotificationsRead(ctx context.Context, q database.Querier, userID uuid.UUID, read NotificationsRead) error {
+	return cqrs.EnqueueEvent(ctx, q, cqrs.EventNotificationRead, "success", cqrs.NotificationReadEventPayload{
+		UserID:          userID.String(),
+		NotificationIDs: read.NotificationIDs,
+		Timestamp:       read.ReadAt.Format(time.RFC3339),
+	})
+}
+
+// MarkNotificationReadHandler maneja el comando MarkNotificationRead
+type MarkNotificationReadHandler struct {
+	store database.Store
+}
+
+// NewMarkNotificationReadHandler crea una nueva instancia del handler
+func NewMarkNotificationReadHandler(store database.Store) *MarkNotificationReadHandler {
+	return &MarkNotificationReadHandler{
+		store: store,
+	}
+}
+
+// Handle implementa la interfaz CommandHandler
+func (h *MarkNotificationReadHandler) Handle(ctx context.Context, cmd MarkNotificationReadCommand) (NotificationsRead, error) {
+	cmd.Store = h.store
+	return cmd.Execute(ctx)
+}
+
+// MarkAllNotificationsReadHandler maneja el comando MarkAllNotificationsRead
+type MarkAllNotificationsReadHandler struct {
+	store database.Store
+}
+
+// NewMarkAllNotificationsReadHandler crea una nueva instancia del handler
+func NewMarkAllNotificationsReadHandler(store database.Store) *MarkAllNotificationsReadHandler {
+	return &MarkAllNotificationsReadHandler{
+		store: store,
+	}
+}
+
+// Handle implementa la interfaz CommandHandler
+func (h *MarkAllNotificationsReadHandler) Handle(ctx context.Context, cmd MarkAllNotificationsReadCommand) (NotificationsRead, error) {
+	cmd.Store = h.store
+	return cmd.Execute(ctx)
+}
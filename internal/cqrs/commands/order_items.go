@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// OrderItem representa una línea solicitada al crear una orden
+type OrderItem struct {
+	DishID   uuid.UUID `validate:"required"`
+	Quantity int       `validate:"gt=0"`
+	Notes    string    `validate:"max=500"`
+}
+
+// OrderResultItem es una línea de la orden retornada por los comandos
+type OrderResultItem struct {
+	ID              string      `json:"id"`
+	DishID          string      `json:"dish_id"`
+	DishName        string      `json:"dish_name"`
+	DishDescription string      `json:"dish_description"`
+	PrepTimeMinutes int         `json:"prep_time_minutes"`
+	Quantity        int         `json:"quantity"`
+	UnitPrice       utils.Money `json:"unit_price"`
+	Subtotal        utils.Money `json:"subtotal"`
+	Notes           string      `json:"notes,omitempty"`
+}
+
+// OrderResult es la orden tal como quedó después de ejecutar un comando
+type OrderResult struct {
+	ID        string            `json:"id"`
+	UserID    string            `json:"user_id"`
+	Status    string            `json:"status"`
+	Items     []OrderResultItem `json:"items"`
+	Total     utils.Money       `json:"total"`
+	Currency  string            `json:"currency"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// loadOrderResult completa una orden con sus líneas y el total
+func loadOrderResult(ctx context.Context, q database.Querier, order database.Order) (OrderResult, error) {
+	rows, err := q.GetOrderItemsByOrderIds(ctx, []pgtype.UUID{order.ID})
+	if err != nil {
+		return OrderResult{}, err
+	}
+
+	items := make([]OrderResultItem, len(rows))
+	total := utils.NewMoney(0, utils.DefaultCurrency)
+	for i, row := range rows {
+		unitPrice, err := utils.FromPgNumeric(row.UnitPrice)
+		if err != nil {
+			return OrderResult{}, err
+		}
+		subtotal, err := unitPrice.Mul(int64(row.Quantity))
+		if err != nil {
+			return OrderResult{}, err
+		}
+		if total, err = total.Add(subtotal); err != nil {
+			return OrderResult{}, err
+		}
+		items[i] = OrderResultItem{
+			ID:              utils.FromPgUUID(row.ID).String(),
+			DishID:          utils.FromPgUUID(row.DishID).String(),
+			DishName:        row.DishName,
+			DishDescription: row.DishDescription.String,
+			PrepTimeMinutes: int(row.DishPrepTimeMinutes),
+			Quantity:        int(row.Quantity),
+			UnitPrice:       unitPrice,
+			Subtotal:        subtotal,
+			Notes:           row.Notes.String,
+		}
+	}
+
+	return OrderResult{
+		ID:        utils.FromPgUUID(order.ID).String(),
+		UserID:    utils.FromPgUUID(order.UserID).String(),
+		Status:    order.Status,
+		Items:     items,
+		Total:     total,
+		Currency:  total.Currency,
+		CreatedAt: order.CreatedAt.Time,
+		UpdatedAt: order.UpdatedAt.Time,
+	}, nil
+}
+
+// orderEventPayload construye el payload de un evento de orden incluyendo sus
+// líneas y el total, para que la cocina pueda mostrar el ticket completo y las
+// proyecciones puedan guardar el historial sin consultar otras tablas
+func orderEventPayload(order OrderResult) cqrs.OrderEventPayload {
+	items := make([]cqrs.OrderItemPayload, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = cqrs.OrderItemPayload{
+			ID:              item.ID,
+			DishID:          item.DishID,
+			DishName:        item.DishName,
+			DishDescription: item.DishDescription,
+			PrepTimeMinutes: item.PrepTimeMinutes,
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			Notes:           item.Notes,
+		}
+	}
+
+	return cqrs.OrderEventPayload{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Items:     items,
+		Total:     order.Total,
+		Currency:  order.Currency,
+		Status:    order.Status,
+		CreatedAt: order.CreatedAt.Format(time.RFC3339Nano),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Estados de una orden (ver el CHECK de orders.status en internal/migrations/sql)
+const (
+	OrderStatusReceived  = "received"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusPreparing = "preparing"
+	OrderStatusServed    = "served"
+	OrderStatusCancelled = "cancelled"
+)
+
+// Roles definidos en la tabla roles
+const (
+	RoleClient  = "client"
+	RoleKitchen = "kitchen"
+	RoleAdmin   = "admin"
+)
+
+// RoleOwner no existe en la tabla roles: UpdateOrderStatusCommand se lo
+// agrega a quien creó la orden
+const RoleOwner = "owner"
+
+// staffRoles son los roles que pueden cambiar el estado de órdenes ajenas
+var staffRoles = []string{RoleKitchen, RoleAdmin}
+
+var (
+	// ErrIllegalTransition indica que la transición no existe en la máquina de estados
+	ErrIllegalTransition = errors.New("transición de estado no permitida")
+	// ErrTransitionForbidden indica que la transición existe pero el usuario no tiene un rol que pueda ejecutarla
+	ErrTransitionForbidden = errors.New("el usuario no puede realizar esta transición de estado")
+)
+
+// TransitionError describe una transición rechazada por la máquina de estados.
+// Envuelve ErrIllegalTransition o ErrTransitionForbidden.
+type TransitionError struct {
+	From string
+	To   string
+	err  error
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s", e.err, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.err
+}
+
+// OrderStateMachine define las transiciones legales entre estados de una
+// orden y qué roles pueden ejecutar cada una. served y cancelled son estados
+// finales: una orden cerrada no puede reabrirse, lo que protege el índice
+// idx_orders_active_user.
+type OrderStateMachine map[string]map[string][]string
+
+// OrderTransitions es la máquina de estados de las órdenes
+var OrderTransitions = OrderStateMachine{
+	OrderStatusReceived: {
+		OrderStatusConfirmed: {RoleKitchen, RoleAdmin},
+		// El cliente puede cancelar su orden mientras la cocina no la confirme
+		OrderStatusCancelled: {RoleKitchen, RoleAdmin, RoleOwner},
+	},
+	OrderStatusConfirmed: {
+		OrderStatusPreparing: {RoleKitchen, RoleAdmin},
+		OrderStatusCancelled: {RoleKitchen, RoleAdmin},
+	},
+	OrderStatusPreparing: {
+		OrderStatusServed: {RoleKitchen, RoleAdmin},
+	},
+	OrderStatusServed:    {},
+	OrderStatusCancelled: {},
+}
+
+// orderStatusOrder define el orden en que se listan los estados
+var orderStatusOrder = []string{
+	OrderStatusReceived,
+	OrderStatusConfirmed,
+	OrderStatusPreparing,
+	OrderStatusServed,
+	OrderStatusCancelled,
+}
+
+// Check valida que un usuario con los roles dados pueda mover una orden de
+// from a to. Retorna un *TransitionError si no puede.
+func (m OrderStateMachine) Check(from, to string, roles []string) error {
+	allowed, ok := m[from][to]
+	if !ok {
+		return &TransitionError{From: from, To: to, err: ErrIllegalTransition}
+	}
+	if !hasAnyRole(roles, allowed) {
+		return &TransitionError{From: from, To: to, err: ErrTransitionForbidden}
+	}
+	return nil
+}
+
+// Next retorna los estados a los que un usuario con los roles dados puede
+// mover una orden que está en from. Con roles nil se retornan todas las
+// transiciones legales, sin filtrar por rol.
+func (m OrderStateMachine) Next(from string, roles []string) []string {
+	var next []string
+	for _, to := range orderStatusOrder {
+		allowed, ok := m[from][to]
+		if !ok {
+			continue
+		}
+		if roles != nil && !hasAnyRole(roles, allowed) {
+			continue
+		}
+		next = append(next, to)
+	}
+	return next
+}
+
+// IsFinal indica si un estado no admite más transiciones
+func (m OrderStateMachine) IsFinal(status string) bool {
+	return len(m[status]) == 0
+}
+
+func hasAnyRole(roles, allowed []string) bool {
+	for _, role := range roles {
+		for _, a := range allowed {
+			if role == a {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,8 +2,10 @@ package commands
 
 import (
 	"context"
+	"errors"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rodrwan/themenu/internal/cqrs"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/utils"
@@ -11,63 +13,90 @@ import (
 
 // UpdateOrderStatusCommand representa el comando para actualizar el estado de una orden
 type UpdateOrderStatusCommand struct {
+	OrderID uuid.UUID `validate:"required"`
+	Status  string    `validate:"oneof=received confirmed preparing served cancelled"`
+	// ActorID es el usuario que solicita el cambio; sus roles determinan qué
+	// transiciones puede realizar
+	ActorID uuid.UUID      `validate:"required"`
+	Store   database.Store `validate:"-"`
+}
+
+// StatusChange describe el cambio de estado aplicado por UpdateOrderStatusCommand
+type StatusChange struct {
 	OrderID  uuid.UUID
+	Previous string
 	Status   string
-	Queries  database.Querier
-	EventBus *cqrs.EventBus
 }
 
-// Execute implementa la interfaz Command
-func (c *UpdateOrderStatusCommand) Execute() error {
-	ctx := context.Background()
+// Execute aplica el cambio de estado y lo retorna
+func (c *UpdateOrderStatusCommand) Execute(ctx context.Context) (StatusChange, error) {
+	change := StatusChange{OrderID: c.OrderID, Status: c.Status}
 
-	// Verificar si la orden existe
-	order, err := c.Queries.GetOrder(ctx, utils.ToPgUUID(c.OrderID))
-	if err != nil {
-		return ErrOrderNotFound
-	}
+	// El cambio de estado y su evento se escriben en la misma transacción
+	err := c.Store.ExecTx(ctx, func(q database.Querier) error {
+		// Verificar si la orden existe, bloqueándola para que dos cambios
+		// concurrentes no partan del mismo estado
+		order, err := q.GetOrderForUpdate(ctx, utils.ToPgUUID(c.OrderID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		change.Previous = order.Status
+
+		// Validar la transición según la máquina de estados. El dueño de la
+		// orden suma RoleOwner; para quien no es dueño ni tiene un rol de
+		// cocina la orden no existe, igual que en el reader
+		roles, err := q.GetUserRoleNames(ctx, utils.ToPgUUID(c.ActorID))
+		if err != nil {
+			return err
+		}
+		if order.UserID == utils.ToPgUUID(c.ActorID) {
+			roles = append(roles, RoleOwner)
+		} else if !hasAnyRole(roles, staffRoles) {
+			return ErrOrderNotFound
+		}
+		if err := OrderTransitions.Check(order.Status, c.Status, roles); err != nil {
+			return err
+		}
 
-	// Actualizar el estado
-	_, err = c.Queries.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
-		ID:     utils.ToPgUUID(c.OrderID),
-		Status: c.Status,
+		// Actualizar el estado
+		updated, err := q.UpdateOrderStatus(ctx, database.UpdateOrderStatusParams{
+			ID:     utils.ToPgUUID(c.OrderID),
+			Status: c.Status,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Registrar el evento de actualización de estado en el outbox
+		result, err := loadOrderResult(ctx, q, updated)
+		if err != nil {
+			return err
+		}
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventOrderStatusUpdated, c.Status, orderEventPayload(result))
 	})
 	if err != nil {
-		return err
+		return StatusChange{}, err
 	}
-
-	// Publicar evento de actualización de estado
-	c.EventBus.PublishEvent(cqrs.EventOrderStatusUpdated, c.Status, map[string]interface{}{
-		"order_id": c.OrderID,
-		"user_id":  utils.FromPgUUID(order.UserID),
-		"dish_id":  utils.FromPgUUID(order.DishID),
-		"status":   c.Status,
-	})
-
-	return nil
+	return change, nil
 }
 
 // UpdateOrderStatusHandler maneja el comando UpdateOrderStatus
 type UpdateOrderStatusHandler struct {
-	db       database.Querier
-	eventBus *cqrs.EventBus
+	store database.Store
 }
 
 // NewUpdateOrderStatusHandler crea una nueva instancia del handler
-func NewUpdateOrderStatusHandler(db database.Querier, eventBus *cqrs.EventBus) *UpdateOrderStatusHandler {
+func NewUpdateOrderStatusHandler(store database.Store) *UpdateOrderStatusHandler {
 	return &UpdateOrderStatusHandler{
-		db:       db,
-		eventBus: eventBus,
+		store: store,
 	}
 }
 
 // Handle implementa la interfaz CommandHandler
-func (h *UpdateOrderStatusHandler) Handle(command Command) error {
-	cmd, ok := command.(*UpdateOrderStatusCommand)
-	if !ok {
-		return ErrInvalidCommand
-	}
-	cmd.Queries = h.db
-	cmd.EventBus = h.eventBus
-	return cmd.Execute()
+func (h *UpdateOrderStatusHandler) Handle(ctx context.Context, cmd UpdateOrderStatusCommand) (StatusChange, error) {
+	cmd.Store = h.store
+	return cmd.Execute(ctx)
 }
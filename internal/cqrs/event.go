@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -22,10 +23,65 @@ type Event struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// eventTransport abstrae el mecanismo de Redis usado para distribuir los eventos
+// entre servicios
+type eventTransport interface {
+	// publish envía un evento serializado al resto de los servicios
+	publish(ctx context.Context, payload []byte) error
+	// consume recibe eventos hasta que se cancela el contexto, entregándolos a
+	// dispatch. Un evento se considera procesado cuando dispatch retorna true.
+	consume(ctx context.Context, dispatch func(Event) bool)
+}
+
+// subscription asocia el canal de un suscriptor local con su filtro
+type subscription struct {
+	ch     chan Event
+	filter EventFilter
+	// mu protege closed y serializa los envíos con el cierre del canal, que
+	// ocurren fuera de EventBus.mu
+	mu     sync.Mutex
+	closed bool
+}
+
+// send entrega el evento al canal con reintentos. Retorna false si el canal
+// siguió lleno; un canal ya cerrado se ignora.
+func (s *subscription) send(event Event) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return true
+	}
+	return sendWithRetry(s.ch, event)
+}
+
+// close cierra el canal una sola vez
+func (s *subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// EventHandler procesa un evento recibido por el EventBus. Si retorna error el
+// evento no se confirma y, con el backend streams, se vuelve a entregar.
+type EventHandler func(event Event) error
+
+// handlerSubscription asocia un EventHandler con su filtro
+type handlerSubscription struct {
+	handler EventHandler
+	filter  EventFilter
+}
+
 // EventBus maneja la distribución de eventos
 type EventBus struct {
 	redisClient  *redis.Client
-	subscribers  map[string][]chan Event
+	transport    eventTransport
+	subscribers  []*subscription
+	handlers     []*handlerSubscription
 	ctx          context.Context
 	cancel       context.CancelFunc
 	mu           sync.RWMutex
@@ -45,6 +101,14 @@ const (
 	RedisBufferSize = 1024 * 1024 // 1MB
 )
 
+// Backends disponibles para el EventBus, seleccionados con EVENT_BUS_BACKEND
+const (
+	// BackendPubSub usa Pub/Sub de Redis (sin garantías de entrega)
+	BackendPubSub = "pubsub"
+	// BackendStreams usa Redis Streams con grupos de consumidores
+	BackendStreams = "streams"
+)
+
 // NewEventBus crea una nueva instancia de EventBus
 func NewEventBus() *EventBus {
 	redisURL := os.Getenv("REDIS_URL")
@@ -72,9 +136,20 @@ func NewEventBus() *EventBus {
 	// Crear contexto con cancelación
 	ctx, cancel := context.WithCancel(context.Background())
 
+	var transport eventTransport
+	switch backend := os.Getenv("EVENT_BUS_BACKEND"); backend {
+	case "", BackendPubSub:
+		transport = newPubSubTransport(client)
+	case BackendStreams:
+		transport = newStreamTransport(client, streamGroupFromEnv(), streamConsumerFromEnv(), streamGroupStartFromEnv())
+	default:
+		cancel()
+		panic(fmt.Sprintf("backend de EventBus desconocido: %s", backend))
+	}
+
 	bus := &EventBus{
 		redisClient:  client,
-		subscribers:  make(map[string][]chan Event),
+		transport:    transport,
 		ctx:          ctx,
 		cancel:       cancel,
 		backpressure: make(chan struct{}, 1),
@@ -86,57 +161,54 @@ func NewEventBus() *EventBus {
 		panic(err)
 	}
 
-	// Iniciar el subscriber de Redis en una goroutine
-	go bus.subscribeToRedis(ctx)
+	// Iniciar el consumidor de Redis en una goroutine
+	go transport.consume(ctx, bus.dispatch)
 
 	return bus
 }
 
-// subscribeToRedis escucha los eventos publicados en Redis
-func (b *EventBus) subscribeToRedis(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			pubsub := b.redisClient.Subscribe(ctx, "events")
-			ch := pubsub.Channel(
-				redis.WithChannelSize(RedisBufferSize),
-			)
-
-			for msg := range ch {
-				var event Event
-				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
-					log.Printf("Error al deserializar evento: %v", err)
-					continue
-				}
-
-				// Verificar backpressure
-				select {
-				case b.backpressure <- struct{}{}:
-					// Reenviar el evento a los suscriptores locales (SSE) sin publicarlo en Redis
-					b.mu.RLock()
-					subscribers := b.subscribers["events"]
-					b.mu.RUnlock()
-					for _, subscriber := range subscribers {
-						select {
-						case subscriber <- event:
-						default:
-							log.Printf("Canal lleno, ignorando evento para suscriptor")
-						}
-					}
-					<-b.backpressure
-				default:
-					log.Printf("Backpressure activado, ignorando evento")
-					time.Sleep(100 * time.Millisecond)
-				}
-			}
+// dispatch reenvía un evento recibido desde Redis a los suscriptores locales.
+// Los handlers se ejecutan antes de confirmar el evento y sólo el error de un
+// handler deja el evento pendiente; con el backend streams se vuelve a
+// entregar, por lo que los demás suscriptores pueden verlo repetido. Un canal
+// que sigue lleno después de MaxRetries intentos se desconecta en vez de
+// retener el evento: el suscriptor lo ve cerrarse y puede reconectarse.
+func (b *EventBus) dispatch(event Event) bool {
+	// Los eventos se despachan de a uno. El lector del stream y el reclamo de
+	// pendientes esperan su turno en vez de descartar el evento.
+	b.backpressure <- struct{}{}
+	defer func() { <-b.backpressure }()
+
+	// Los envíos y los handlers pueden tardar, así que se trabaja sobre una
+	// copia de los suscriptores para no bloquear las suscripciones nuevas ni
+	// las desconexiones. Un handler eliminado mientras tanto puede recibir
+	// este último evento.
+	b.mu.RLock()
+	subscribers := slices.Clone(b.subscribers)
+	handlers := slices.Clone(b.handlers)
+	b.mu.RUnlock()
 
-			// Si llegamos aquí, la conexión se cerró
-			log.Println("Conexión Redis cerrada, intentando reconectar...")
-			time.Sleep(time.Second) // Esperar antes de reconectar
+	for _, subscriber := range subscribers {
+		if !subscriber.filter.Matches(event) {
+			continue
+		}
+		if !subscriber.send(event) {
+			log.Printf("Desconectando un suscriptor lento")
+			b.remove(subscriber)
+		}
+	}
+
+	delivered := true
+	for _, subscriber := range handlers {
+		if !subscriber.filter.Matches(event) {
+			continue
+		}
+		if err := subscriber.handler(event); err != nil {
+			log.Printf("Error al procesar el evento %s, quedará pendiente: %v", event.ID, err)
+			delivered = false
 		}
 	}
+	return delivered
 }
 
 // Close cierra la conexión con Redis
@@ -147,89 +219,130 @@ func (b *EventBus) Close() {
 	}
 }
 
-// Subscribe registra un nuevo suscriptor para un tipo de evento
+// Subscribe registra un nuevo suscriptor para un tipo de evento. El tipo puede
+// ser un patrón con comodines (por ejemplo "Order*"); vacío o '*' recibe todos
+// los eventos.
 func (b *EventBus) Subscribe(eventType string) <-chan Event {
-	ch := make(chan Event, 100)
+	return b.SubscribeFilter(EventFilter{Types: splitList(eventType)})
+}
+
+// SubscribeFilter registra un nuevo suscriptor que sólo recibe los eventos que
+// cumplen con el filtro
+func (b *EventBus) SubscribeFilter(filter EventFilter) <-chan Event {
+	subscriber := &subscription{ch: make(chan Event, 100), filter: filter}
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	// Si el tipo de evento es vacío o '*', suscribirse al canal general 'events'
-	channel := "events"
-	if eventType != "" && eventType != "*" {
-		channel = fmt.Sprintf("events:%s", eventType)
-	}
-
-	b.subscribers[channel] = append(b.subscribers[channel], ch)
-	return ch
+	b.subscribers = append(b.subscribers, subscriber)
+	return subscriber.ch
 }
 
-// Unsubscribe elimina un suscriptor
-func (b *EventBus) Unsubscribe(eventType string, ch chan Event) {
+// SubscribeHandler registra un handler que procesa los eventos que cumplen con
+// el filtro. A diferencia de SubscribeFilter, el evento sólo se confirma si el
+// handler termina sin error. Retorna una función que elimina la suscripción.
+func (b *EventBus) SubscribeHandler(filter EventFilter, handler EventHandler) func() {
+	subscriber := &handlerSubscription{handler: handler, filter: filter}
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	subscribers := b.subscribers[eventType]
-	for i, subscriber := range subscribers {
-		if subscriber == ch {
-			// Cerrar el canal
-			close(ch)
-			// Eliminar el suscriptor
-			b.subscribers[eventType] = append(subscribers[:i], subscribers[i+1:]...)
-			return
+	b.handlers = append(b.handlers, subscriber)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		for i, h := range b.handlers {
+			if h == subscriber {
+				b.handlers = append(b.handlers[:i], b.handlers[i+1:]...)
+				return
+			}
 		}
 	}
 }
 
-// Publish envía un evento a todos los suscriptores del tipo especificado
-func (b *EventBus) Publish(event Event) {
-	// Publicar en Redis en el canal específico del tipo de evento
-	eventBytes, err := json.Marshal(event)
-	if err != nil {
-		log.Printf("Error al serializar evento: %v", err)
-		return
+// Unsubscribe elimina un suscriptor y cierra su canal. No hace nada si el
+// suscriptor ya fue desconectado por lento.
+func (b *EventBus) Unsubscribe(ch <-chan Event) {
+	b.mu.RLock()
+	var found *subscription
+	for _, subscriber := range b.subscribers {
+		if subscriber.ch == ch {
+			found = subscriber
+			break
+		}
 	}
+	b.mu.RUnlock()
 
-	// redisChannel := "events:" + event.Type
-	// log.Printf("Publicando evento en Redis: %s", redisChannel)
-	// if err := b.redisClient.Publish(b.ctx, redisChannel, string(eventBytes)).Err(); err != nil {
-	// 	log.Printf("Error al publicar evento en Redis: %v", err)
-	// }
+	if found != nil {
+		b.remove(found)
+	}
+}
+
+// remove elimina un suscriptor y cierra su canal. El canal se cierra fuera de
+// b.mu porque puede esperar a un envío en curso.
+func (b *EventBus) remove(subscriber *subscription) {
+	b.mu.Lock()
+	b.subscribers = slices.DeleteFunc(b.subscribers, func(s *subscription) bool {
+		return s == subscriber
+	})
+	b.mu.Unlock()
 
-	// También publicar en el canal general para compatibilidad
-	if err := b.redisClient.Publish(b.ctx, "events", string(eventBytes)).Err(); err != nil {
+	subscriber.close()
+}
+
+// Publish envía un evento a todos los suscriptores del tipo especificado
+func (b *EventBus) Publish(event Event) {
+	if err := b.Deliver(b.ctx, event); err != nil {
 		log.Printf("Error al publicar evento en Redis: %v", err)
 	}
 }
 
-// sendWithRetry intenta enviar un evento a un canal con reintentos
-func (b *EventBus) sendWithRetry(ch chan Event, event Event) {
+// Deliver publica un evento en Redis y retorna el error si no se pudo entregar
+func (b *EventBus) Deliver(ctx context.Context, event Event) error {
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error al serializar evento: %w", err)
+	}
+	return b.transport.publish(ctx, eventBytes)
+}
+
+// sendWithRetry intenta enviar un evento a un canal con reintentos. Retorna
+// false si el canal siguió lleno después de MaxRetries intentos.
+func sendWithRetry(ch chan Event, event Event) bool {
 	for i := 0; i < MaxRetries; i++ {
 		select {
 		case ch <- event:
-			return
+			return true
 		default:
 			if i < MaxRetries-1 {
 				time.Sleep(RetryDelay)
-			} else {
-				log.Printf("No se pudo enviar el evento después de %d intentos", MaxRetries)
 			}
 		}
 	}
+	log.Printf("No se pudo enviar el evento %s después de %d intentos", event.ID, MaxRetries)
+	return false
 }
 
 // PublishEvent es un helper para publicar eventos desde el backend
 func (b *EventBus) PublishEvent(eventType, status string, payload interface{}) error {
-	payloadBytes, err := json.Marshal(payload)
+	event, err := NewEvent(eventType, status, payload)
 	if err != nil {
 		return err
 	}
-	event := Event{
+	b.Publish(event)
+	return nil
+}
+
+// NewEvent construye un evento serializando su payload a JSON
+func NewEvent(eventType, status string, payload interface{}) (Event, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
 		ID:        uuid.New().String(),
 		Type:      eventType,
 		Status:    status,
 		Payload:   string(payloadBytes),
 		Timestamp: time.Now(),
-	}
-	b.Publish(event)
-	return nil
+	}, nil
 }
@@ -1,5 +1,7 @@
 package cqrs
 
+import "github.com/rodrwan/themenu/internal/utils"
+
 // Tipos de eventos del sistema
 const (
 	// Eventos de Usuario
@@ -21,6 +23,7 @@ const (
 
 	// Eventos de Notificación
 	EventNotificationSent = "NotificationSent"
+	EventNotificationRead = "NotificationRead"
 
 	// Eventos de Sistema
 	EventSystemError = "SystemError"
@@ -29,6 +32,26 @@ const (
 	EventTokenGenerated = "token.generated"
 )
 
+// EventTypes son todos los tipos de eventos del sistema. El dashboard del web
+// los usa para escuchar cada tipo en el stream SSE.
+var EventTypes = []string{
+	EventUserCreated,
+	EventUserUpdated,
+	EventUserDeleted,
+	EventDishCreated,
+	EventDishUpdated,
+	EventDishDeleted,
+	EventOrderCreated,
+	EventOrderStatusUpdated,
+	EventOrderCancelled,
+	EventOrderUpdated,
+	EventOrderDeleted,
+	EventNotificationSent,
+	EventNotificationRead,
+	EventSystemError,
+	EventTokenGenerated,
+}
+
 // Payloads de eventos
 type (
 	// UserEventPayload representa el payload para eventos de usuario
@@ -41,22 +64,39 @@ type (
 
 	// DishEventPayload representa el payload para eventos de plato
 	DishEventPayload struct {
-		DishID          string  `json:"dish_id"`
-		Name            string  `json:"name"`
-		Description     string  `json:"description"`
-		Price           float64 `json:"price"`
-		PrepTimeMinutes int     `json:"prep_time_minutes"`
-		AvailableOn     string  `json:"available_on"`
-		Timestamp       string  `json:"timestamp"`
+		DishID          string      `json:"dish_id"`
+		Name            string      `json:"name"`
+		Description     string      `json:"description"`
+		Price           utils.Money `json:"price"`
+		Currency        string      `json:"currency"`
+		PrepTimeMinutes int         `json:"prep_time_minutes"`
+		AvailableOn     string      `json:"available_on"`
+		Timestamp       string      `json:"timestamp"`
 	}
 
 	// OrderEventPayload representa el payload para eventos de orden
 	OrderEventPayload struct {
-		OrderID   string `json:"order_id"`
-		UserID    string `json:"user_id"`
-		DishID    string `json:"dish_id"`
-		Status    string `json:"status"`
-		Timestamp string `json:"timestamp"`
+		OrderID   string             `json:"order_id"`
+		UserID    string             `json:"user_id"`
+		Items     []OrderItemPayload `json:"items"`
+		Total     utils.Money        `json:"total"`
+		Currency  string             `json:"currency"`
+		Status    string             `json:"status"`
+		CreatedAt string             `json:"created_at"`
+		Timestamp string             `json:"timestamp"`
+	}
+
+	// OrderItemPayload representa una línea de la orden dentro de los eventos
+	// de orden, con los datos del plato al momento del evento
+	OrderItemPayload struct {
+		ID              string      `json:"id"`
+		DishID          string      `json:"dish_id"`
+		DishName        string      `json:"dish_name"`
+		DishDescription string      `json:"dish_description"`
+		PrepTimeMinutes int         `json:"prep_time_minutes"`
+		Quantity        int         `json:"quantity"`
+		UnitPrice       utils.Money `json:"unit_price"`
+		Notes           string      `json:"notes,omitempty"`
 	}
 
 	// NotificationEventPayload representa el payload para eventos de notificación
@@ -64,8 +104,20 @@ type (
 		NotificationID string `json:"notification_id"`
 		UserID         string `json:"user_id"`
 		OrderID        string `json:"order_id"`
-		Message        string `json:"message"`
-		Timestamp      string `json:"timestamp"`
+		// Status es el estado de la orden que originó la notificación
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		// Channels son los canales que entregaron la notificación
+		Channels  []string `json:"channels"`
+		Timestamp string   `json:"timestamp"`
+	}
+
+	// NotificationReadEventPayload representa el payload del evento de
+	// notificaciones marcadas como leídas
+	NotificationReadEventPayload struct {
+		UserID          string   `json:"user_id"`
+		NotificationIDs []string `json:"notification_ids"`
+		Timestamp       string   `json:"timestamp"`
 	}
 
 	// SystemEventPayload representa el payload para eventos del sistema
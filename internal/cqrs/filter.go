@@ -0,0 +1,118 @@
+package cqrs
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// EventFilter selecciona los eventos que recibe un suscriptor
+type EventFilter struct {
+	// Types contiene patrones de tipo de evento. Un '*' coincide con cualquier
+	// secuencia de caracteres, por lo que "Order*" incluye OrderCreated y
+	// OrderStatusUpdated. También se acepta ".*" (estilo expresión regular),
+	// de modo que "Dish.*" equivale a "Dish*". Vacío incluye todos los tipos.
+	Types []string
+	// Statuses contiene los valores de Event.Status aceptados. Vacío incluye
+	// todos los estados.
+	Statuses []string
+	// UserIDs contiene los IDs de usuario aceptados, comparados con el campo
+	// user_id del payload. Vacío incluye los eventos de todos los usuarios.
+	UserIDs []string
+}
+
+// ParseEventFilter construye un filtro a partir de listas separadas por comas,
+// como las recibidas en los parámetros ?types= y ?status= del endpoint SSE
+func ParseEventFilter(types, statuses string) EventFilter {
+	return EventFilter{
+		Types:    splitList(types),
+		Statuses: splitList(statuses),
+	}
+}
+
+// Matches indica si un evento cumple con el filtro
+func (f EventFilter) Matches(event Event) bool {
+	return f.matchesType(event.Type) && f.matchesStatus(event.Status) && f.matchesUser(event.Payload)
+}
+
+func (f EventFilter) matchesType(eventType string) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, pattern := range f.Types {
+		if matchPattern(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f EventFilter) matchesStatus(status string) bool {
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, s := range f.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (f EventFilter) matchesUser(payload string) bool {
+	if len(f.UserIDs) == 0 {
+		return true
+	}
+	var owner struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal([]byte(payload), &owner); err != nil || owner.UserID == "" {
+		return false
+	}
+	for _, id := range f.UserIDs {
+		if id == owner.UserID {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern compara un tipo de evento contra un patrón con comodines '*'
+func matchPattern(pattern, value string) bool {
+	pattern = strings.ReplaceAll(pattern, ".*", "*")
+	if pattern == "" || pattern == "*" {
+		return true
+	}
+
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+
+	// El primer segmento debe ser prefijo y el último sufijo; los
+	// intermedios deben aparecer en orden
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
+
+// splitList separa una lista por comas descartando los elementos vacíos
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
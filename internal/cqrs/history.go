@@ -0,0 +1,75 @@
+package cqrs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// aggregates asocia el prefijo del tipo de evento con la entidad a la que se
+// refiere y el campo del payload que tiene su ID
+var aggregates = []struct {
+	prefix        string
+	aggregateType string
+	field         string
+}{
+	{"Order", "order", "order_id"},
+	{"Dish", "dish", "dish_id"},
+	{"User", "user", "user_id"},
+	{"Notification", "notification", "notification_id"},
+}
+
+// RecordEvent agrega un evento al historial (la tabla events). EnqueueEvent lo
+// llama en la misma transacción que escribe el outbox, de modo que todo evento
+// publicado queda en el historial. Un evento ya guardado se ignora.
+func RecordEvent(ctx context.Context, q database.Querier, event Event) error {
+	eventID, err := uuid.Parse(event.ID)
+	if err != nil {
+		return fmt.Errorf("ID de evento inválido: %w", err)
+	}
+	if !json.Valid([]byte(event.Payload)) {
+		return fmt.Errorf("payload inválido")
+	}
+
+	params := database.AppendEventParams{
+		ID:         utils.ToPgUUID(eventID),
+		EventType:  event.Type,
+		Status:     event.Status,
+		Payload:    []byte(event.Payload),
+		OccurredAt: utils.ToPgTimestamp(event.Timestamp.UTC()),
+	}
+	if aggregateType, aggregateID, ok := EventAggregate(event); ok {
+		params.AggregateType = utils.ToPgText(aggregateType)
+		params.AggregateID = utils.ToPgUUID(aggregateID)
+	}
+
+	_, err = q.AppendEvent(ctx, params)
+	return err
+}
+
+// EventAggregate retorna la entidad a la que se refiere un evento según el
+// prefijo de su tipo, por ejemplo la orden de un OrderCreated. ok es false si
+// el tipo no tiene entidad o el payload no incluye su ID.
+func EventAggregate(event Event) (aggregateType string, id uuid.UUID, ok bool) {
+	for _, aggregate := range aggregates {
+		if !strings.HasPrefix(event.Type, aggregate.prefix) {
+			continue
+		}
+		var payload map[string]any
+		if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+			return "", uuid.Nil, false
+		}
+		value, _ := payload[aggregate.field].(string)
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return "", uuid.Nil, false
+		}
+		return aggregate.aggregateType, id, true
+	}
+	return "", uuid.Nil, false
+}
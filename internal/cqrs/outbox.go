@@ -0,0 +1,126 @@
+package cqrs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+const (
+	// OutboxPollInterval define cada cuánto el relay busca eventos pendientes
+	OutboxPollInterval = time.Second
+	// OutboxBatchSize define cuántos eventos se publican por ciclo del relay
+	OutboxBatchSize = 100
+	// OutboxMaxBackoff define la espera máxima entre reintentos de un evento
+	OutboxMaxBackoff = 5 * time.Minute
+)
+
+// EnqueueEvent escribe un evento en la tabla outbox y en el historial. Debe
+// llamarse con el Querier de la misma transacción que realiza el cambio de
+// dominio, de modo que el evento sólo exista si el cambio se confirma.
+func EnqueueEvent(ctx context.Context, q database.Querier, eventType, status string, payload interface{}) error {
+	event, err := NewEvent(eventType, status, payload)
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.CreateOutboxEvent(ctx, database.CreateOutboxEventParams{
+		ID:        utils.ToPgUUID(uuid.MustParse(event.ID)),
+		EventType: event.Type,
+		Status:    event.Status,
+		Payload:   []byte(event.Payload),
+		CreatedAt: utils.ToPgTimestamp(event.Timestamp),
+	}); err != nil {
+		return err
+	}
+	return RecordEvent(ctx, q, event)
+}
+
+// EventDeliverer publica un evento y reporta si la entrega falló
+type EventDeliverer interface {
+	Deliver(ctx context.Context, event Event) error
+}
+
+// OutboxRelay publica en el EventBus los eventos pendientes de la tabla outbox
+type OutboxRelay struct {
+	store     database.Store
+	publisher EventDeliverer
+}
+
+// NewOutboxRelay crea una nueva instancia del relay
+func NewOutboxRelay(store database.Store, publisher EventDeliverer) *OutboxRelay {
+	return &OutboxRelay{
+		store:     store,
+		publisher: publisher,
+	}
+}
+
+// Run publica los eventos pendientes hasta que se cancela el contexto
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.relay(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Error al procesar el outbox: %v", err)
+			}
+		}
+	}
+}
+
+// relay publica un lote de eventos pendientes. Las filas se bloquean con
+// FOR UPDATE SKIP LOCKED, por lo que varias instancias del writer pueden
+// ejecutar el relay sin publicar dos veces el mismo evento.
+func (r *OutboxRelay) relay(ctx context.Context) error {
+	return r.store.ExecTx(ctx, func(q database.Querier) error {
+		pending, err := q.GetPendingOutboxEvents(ctx, OutboxBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range pending {
+			event := Event{
+				ID:        utils.FromPgUUID(row.ID).String(),
+				Type:      row.EventType,
+				Status:    row.Status,
+				Payload:   string(row.Payload),
+				Timestamp: row.CreatedAt.Time,
+			}
+
+			if err := r.publisher.Deliver(ctx, event); err != nil {
+				log.Printf("Error al publicar evento %s (intento %d): %v", event.ID, row.Attempts+1, err)
+				if err := q.MarkOutboxEventFailed(ctx, database.MarkOutboxEventFailedParams{
+					ID:            row.ID,
+					LastError:     utils.ToPgText(err.Error()),
+					NextAttemptAt: utils.ToPgTimestamp(time.Now().Add(outboxBackoff(row.Attempts))),
+				}); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := q.MarkOutboxEventDelivered(ctx, row.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// outboxBackoff calcula la espera exponencial antes del siguiente intento
+func outboxBackoff(attempts int32) time.Duration {
+	backoff := OutboxPollInterval << min(attempts, 16)
+	if backoff > OutboxMaxBackoff {
+		return OutboxMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,8 @@
+package pipeline
+
+import "errors"
+
+var (
+	ErrValidation = errors.New("datos inválidos")
+	ErrPanic      = errors.New("el handler entró en pánico")
+)
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LatencyObserver recibe la duración de cada ejecución
+type LatencyObserver interface {
+	Observe(name string, duration time.Duration, err error)
+}
+
+// Latency mide la duración de cada ejecución y la entrega al observer. Permite
+// conectar cualquier sistema de métricas; LatencyStats es la implementación
+// en memoria incluida.
+func Latency(observer LatencyObserver) Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, message any) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, message)
+			observer.Observe(Name(message), time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// LatencySummary resume las ejecuciones de un tipo de mensaje
+type LatencySummary struct {
+	Count   int64         `json:"count"`
+	Errors  int64         `json:"errors"`
+	Total   time.Duration `json:"total_ns"`
+	Max     time.Duration `json:"max_ns"`
+	Last    time.Duration `json:"last_ns"`
+	Average time.Duration `json:"average_ns"`
+}
+
+// LatencyStats acumula la latencia por tipo de mensaje en memoria
+type LatencyStats struct {
+	mu    sync.Mutex
+	stats map[string]*LatencySummary
+}
+
+// NewLatencyStats crea un acumulador de latencias vacío
+func NewLatencyStats() *LatencyStats {
+	return &LatencyStats{
+		stats: make(map[string]*LatencySummary),
+	}
+}
+
+// Observe implementa la interfaz LatencyObserver
+func (s *LatencyStats) Observe(name string, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary, ok := s.stats[name]
+	if !ok {
+		summary = &LatencySummary{}
+		s.stats[name] = summary
+	}
+
+	summary.Count++
+	if err != nil {
+		summary.Errors++
+	}
+	summary.Total += duration
+	summary.Last = duration
+	if duration > summary.Max {
+		summary.Max = duration
+	}
+}
+
+// Snapshot retorna una copia de las latencias acumuladas por tipo de mensaje
+func (s *LatencyStats) Snapshot() map[string]LatencySummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]LatencySummary, len(s.stats))
+	for name, summary := range s.stats {
+		copied := *summary
+		copied.Average = copied.Total / time.Duration(copied.Count)
+		snapshot[name] = copied
+	}
+	return snapshot
+}
@@ -0,0 +1,35 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Logging registra cada ejecución con el nombre del mensaje, su duración y el
+// error, si lo hubo. Con logger nil se usa slog.Default().
+func Logging(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, message any) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, message)
+
+			attrs := []slog.Attr{
+				slog.String("message", Name(message)),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				logger.LogAttrs(ctx, slog.LevelError, "mensaje fallido", attrs...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelInfo, "mensaje procesado", attrs...)
+			}
+
+			return result, err
+		}
+	}
+}
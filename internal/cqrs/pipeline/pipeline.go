@@ -0,0 +1,36 @@
+package pipeline
+
+import (
+	"context"
+	"reflect"
+)
+
+// HandleFunc ejecuta un comando o consulta ya resuelto a su handler
+type HandleFunc func(ctx context.Context, message any) (any, error)
+
+// Middleware envuelve la ejecución de los comandos o consultas de un bus. Un
+// middleware puede actuar antes y después de llamar a next, o no llamarlo.
+type Middleware func(next HandleFunc) HandleFunc
+
+// Chain compone los middlewares alrededor de handle. El primer middleware es
+// el más externo, igual que en gin: Chain(h, a, b) ejecuta a, luego b y
+// finalmente h.
+func Chain(handle HandleFunc, middlewares ...Middleware) HandleFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handle = middlewares[i](handle)
+	}
+	return handle
+}
+
+// Name retorna el nombre del tipo de un comando o consulta, por ejemplo
+// "CreateOrderCommand"
+func Name(message any) string {
+	t := reflect.TypeOf(message)
+	if t == nil {
+		return "nil"
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -0,0 +1,25 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// Recovery convierte un pánico del handler en un error que envuelve ErrPanic,
+// de modo que un comando defectuoso no tumbe el servicio
+func Recovery() Middleware {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, message any) (result any, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Pánico al procesar %s: %v\n%s", Name(message), r, debug.Stack())
+					result = nil
+					err = fmt.Errorf("%w: %s: %v", ErrPanic, Name(message), r)
+				}
+			}()
+			return next(ctx, message)
+		}
+	}
+}
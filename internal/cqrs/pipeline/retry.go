@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// sqlStateDeadlockDetected es el código SQLSTATE de un deadlock. Los comandos
+// corren en transacciones READ COMMITTED (ver database.SQLStore.ExecTx), donde
+// Postgres no produce fallos de serialización (40001), así que el deadlock es
+// el único error que un reintento puede resolver.
+const sqlStateDeadlockDetected = "40P01"
+
+// RetryPolicy configura el middleware Retry
+type RetryPolicy struct {
+	// MaxAttempts es el número total de intentos, incluyendo el primero
+	MaxAttempts int
+	// Backoff es la espera antes del primer reintento; se duplica en cada uno
+	Backoff time.Duration
+	// Retryable decide si un error justifica otro intento. Por defecto se
+	// reintentan los deadlocks de Postgres.
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy reintenta hasta tres veces los deadlocks
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     50 * time.Millisecond,
+	Retryable:   IsDeadlock,
+}
+
+// Retry vuelve a ejecutar el handler cuando falla con un error reintentable.
+// Como cada comando abre su propia transacción, un reintento parte de cero.
+func Retry(policy RetryPolicy) Middleware {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsDeadlock
+	}
+
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, message any) (any, error) {
+			backoff := policy.Backoff
+			for attempt := 1; ; attempt++ {
+				result, err := next(ctx, message)
+				if err == nil || attempt >= policy.MaxAttempts || !policy.Retryable(err) {
+					return result, err
+				}
+
+				log.Printf("Reintentando %s (intento %d de %d): %v", Name(message), attempt+1, policy.MaxAttempts, err)
+
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(backoff):
+				}
+				backoff *= 2
+			}
+		}
+	}
+}
+
+// IsDeadlock indica si el error es un deadlock de Postgres
+func IsDeadlock(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == sqlStateDeadlockDetected
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validatable lo implementan los mensajes con reglas que no se pueden
+// expresar con tags
+type Validatable interface {
+	Validate() error
+}
+
+// Validation valida los mensajes antes de llamar al handler. Las reglas se
+// declaran con tags `validate` en los campos (ver go-playground/validator) y,
+// opcionalmente, con un método Validate. Los errores envuelven ErrValidation.
+func Validation() Middleware {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx context.Context, message any) (any, error) {
+			if isStruct(message) {
+				if err := validate.Struct(message); err != nil {
+					return nil, validationError(err)
+				}
+			}
+			if v, ok := message.(Validatable); ok {
+				if err := v.Validate(); err != nil {
+					return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+				}
+			}
+			return next(ctx, message)
+		}
+	}
+}
+
+// validationError traduce los errores del validador a un mensaje legible
+func validationError(err error) error {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	fields := make([]string, len(fieldErrors))
+	for i, fe := range fieldErrors {
+		if fe.Param() != "" {
+			fields[i] = fmt.Sprintf("%s (%s=%s)", fe.Namespace(), fe.Tag(), fe.Param())
+		} else {
+			fields[i] = fmt.Sprintf("%s (%s)", fe.Namespace(), fe.Tag())
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrValidation, strings.Join(fields, ", "))
+}
+
+func isStruct(message any) bool {
+	t := reflect.TypeOf(message)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Struct
+}
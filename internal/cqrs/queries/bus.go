@@ -1,48 +1,127 @@
 package queries
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 
-// QueryBus implementa el bus de consultas
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
+)
+
+// handlerEntry guarda un handler registrado junto con el tipo de su resultado
+type handlerEntry struct {
+	resultType reflect.Type
+	handle     func(ctx context.Context, query any) (any, error)
+}
+
+// QueryBus implementa el bus de consultas. Los handlers se registran por el
+// tipo Go de la consulta, por lo que no hay nombres que mantener sincronizados.
 type QueryBus struct {
-	handlers map[string]QueryHandler
-	mu       sync.RWMutex
+	handlers    map[reflect.Type]handlerEntry
+	middlewares []pipeline.Middleware
+	mu          sync.RWMutex
 }
 
 // NewQueryBus crea una nueva instancia del QueryBus
 func NewQueryBus() *QueryBus {
 	return &QueryBus{
-		handlers: make(map[string]QueryHandler),
+		handlers: make(map[reflect.Type]handlerEntry),
+	}
+}
+
+// Register registra el handler para las consultas de tipo Q. Retorna
+// ErrDuplicateHandler si el tipo ya tenía un handler.
+func Register[Q any, R any](bus *QueryBus, handler QueryHandler[Q, R]) error {
+	queryType := reflect.TypeFor[Q]()
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if _, exists := bus.handlers[queryType]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateHandler, queryType)
+	}
+
+	bus.handlers[queryType] = handlerEntry{
+		resultType: reflect.TypeFor[R](),
+		handle: func(ctx context.Context, query any) (any, error) {
+			return handler.Handle(ctx, query.(Q))
+		},
 	}
+	return nil
 }
 
-// Register registra un handler para un tipo de consulta
-func (b *QueryBus) Register(queryType string, handler QueryHandler) {
+// Dispatch envía una consulta a su handler y retorna el resultado tipado
+func Dispatch[Q any, R any](ctx context.Context, dispatcher QueryDispatcher, query Q) (R, error) {
+	var zero R
+
+	result, err := dispatcher.dispatch(ctx, query)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := result.(R)
+	if !ok {
+		return zero, fmt.Errorf("%w: %T retorna %T", ErrResultType, query, result)
+	}
+	return typed, nil
+}
+
+// Use agrega middlewares a la cadena que envuelve a todos los handlers. Se
+// ejecutan en el orden en que se agregan, antes del handler.
+func (b *QueryBus) Use(middlewares ...pipeline.Middleware) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.handlers[queryType] = handler
+	b.middlewares = append(b.middlewares, middlewares...)
 }
 
-// Dispatch envía una consulta a su handler correspondiente
-func (b *QueryBus) Dispatch(query Query) (interface{}, error) {
+// dispatch implementa la interfaz QueryDispatcher
+func (b *QueryBus) dispatch(ctx context.Context, query any) (any, error) {
 	b.mu.RLock()
-	handler, exists := b.handlers[getQueryType(query)]
+	entry, exists := b.handlers[reflect.TypeOf(query)]
+	middlewares := b.middlewares
 	b.mu.RUnlock()
 
 	if !exists {
-		return nil, ErrInvalidQuery
+		return nil, fmt.Errorf("%w: %T", ErrInvalidQuery, query)
 	}
 
-	return handler.Handle(query)
+	return pipeline.Chain(entry.handle, middlewares...)(ctx, query)
+}
+
+// Requirement describe una consulta que debe tener un handler registrado con
+// un tipo de resultado determinado
+type Requirement struct {
+	query  reflect.Type
+	result reflect.Type
 }
 
-// getQueryType retorna el tipo de consulta
-func getQueryType(query Query) string {
-	switch query.(type) {
-	case *GetMenuQuery:
-		return "GetMenu"
-	case *GetUserOrdersQuery:
-		return "GetUserOrders"
-	default:
-		return "Unknown"
+// Require declara que la consulta Q debe tener un handler que retorne R
+func Require[Q any, R any]() Requirement {
+	return Requirement{
+		query:  reflect.TypeFor[Q](),
+		result: reflect.TypeFor[R](),
+	}
+}
+
+// Verify comprueba que todas las consultas requeridas tengan un handler con
+// el tipo de resultado esperado. Se usa al iniciar el servicio para detectar
+// registros faltantes antes de recibir requests.
+func (b *QueryBus) Verify(requirements ...Requirement) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var errs []error
+	for _, req := range requirements {
+		entry, exists := b.handlers[req.query]
+		if !exists {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrHandlerNotRegistered, req.query))
+			continue
+		}
+		if entry.resultType != req.result {
+			errs = append(errs, fmt.Errorf("%w: %s retorna %s, se esperaba %s", ErrResultType, req.query, entry.resultType, req.result))
+		}
 	}
+	return errors.Join(errs...)
 }
@@ -4,5 +4,12 @@ import "errors"
 
 var (
 	ErrInvalidQuery = errors.New("consulta inválida")
-	ErrMenuNotFound = errors.New("menú no encontrado para la fecha especificada")
+
+	ErrDuplicateHandler     = errors.New("la consulta ya tiene un handler registrado")
+	ErrHandlerNotRegistered = errors.New("la consulta no tiene un handler registrado")
+	ErrResultType           = errors.New("el handler retorna un tipo de resultado distinto al esperado")
+
+	ErrMenuNotFound  = errors.New("menú no encontrado para la fecha especificada")
+	ErrOrderNotFound = errors.New("orden no encontrada")
+	ErrInvalidCursor = errors.New("cursor de paginación inválido")
 )
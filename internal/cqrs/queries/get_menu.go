@@ -10,25 +10,27 @@ import (
 
 // MenuItem representa un plato en el menú
 type MenuItem struct {
-	ID              string    `json:"id"`
-	Name            string    `json:"name"`
-	Description     string    `json:"description"`
-	Price           float64   `json:"price"`
-	PrepTimeMinutes int       `json:"prep_time_minutes"`
-	AvailableOn     time.Time `json:"available_on"`
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	Description     string      `json:"description"`
+	Price           utils.Money `json:"price"`
+	Currency        string      `json:"currency"`
+	PrepTimeMinutes int         `json:"prep_time_minutes"`
+	AvailableOn     time.Time   `json:"available_on"`
 }
 
 // GetMenuQuery representa la consulta para obtener el menú del día
 type GetMenuQuery struct {
-	Date    time.Time
-	Queries database.Querier
+	Date    time.Time        `validate:"required"`
+	Queries database.Querier `validate:"-"`
 }
 
-func (q *GetMenuQuery) Execute() (interface{}, error) {
-	ctx := context.Background()
-
+// Execute retorna los platos disponibles en la fecha de la consulta. Los lee
+// de la proyección menu_by_date, por lo que los cambios a los platos se ven
+// cuando la proyección aplica sus eventos.
+func (q *GetMenuQuery) Execute(ctx context.Context) ([]MenuItem, error) {
 	// Obtener los platos disponibles para la fecha especificada
-	dishes, err := q.Queries.GetDishesByDate(ctx, utils.ToPgDate(q.Date))
+	dishes, err := q.Queries.GetMenuByDate(ctx, utils.ToPgDate(q.Date))
 	if err != nil {
 		return nil, err
 	}
@@ -40,11 +42,16 @@ func (q *GetMenuQuery) Execute() (interface{}, error) {
 	// Convertir los platos al formato de respuesta
 	menuItems := make([]MenuItem, len(dishes))
 	for i, dish := range dishes {
+		price, err := utils.FromPgNumeric(dish.Price)
+		if err != nil {
+			return nil, err
+		}
 		menuItems[i] = MenuItem{
-			ID:              utils.FromPgUUID(dish.ID).String(),
+			ID:              utils.FromPgUUID(dish.DishID).String(),
 			Name:            dish.Name,
-			Description:     dish.Description.String,
-			Price:           utils.ToFloat64(dish.Price),
+			Description:     dish.Description,
+			Price:           price,
+			Currency:        price.Currency,
 			PrepTimeMinutes: int(dish.PrepTimeMinutes),
 			AvailableOn:     dish.AvailableOn.Time,
 		}
@@ -64,11 +71,8 @@ func NewGetMenuHandler(queries database.Querier) *GetMenuHandler {
 	}
 }
 
-func (h *GetMenuHandler) Handle(query Query) (interface{}, error) {
-	q, ok := query.(*GetMenuQuery)
-	if !ok {
-		return nil, ErrInvalidQuery
-	}
+// Handle implementa la interfaz QueryHandler
+func (h *GetMenuHandler) Handle(ctx context.Context, q GetMenuQuery) ([]MenuItem, error) {
 	q.Queries = h.queries
-	return q.Execute()
+	return q.Execute(ctx)
 }
@@ -0,0 +1,52 @@
+package queries
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// GetOrderQuery representa la consulta para obtener una orden por su ID
+type GetOrderQuery struct {
+	OrderID uuid.UUID        `validate:"required"`
+	Queries database.Querier `validate:"-"`
+}
+
+// Execute retorna la orden con sus líneas
+func (q *GetOrderQuery) Execute(ctx context.Context) (OrderDetail, error) {
+	order, err := q.Queries.GetOrder(ctx, utils.ToPgUUID(q.OrderID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return OrderDetail{}, ErrOrderNotFound
+		}
+		return OrderDetail{}, err
+	}
+
+	details, err := orderDetails(ctx, q.Queries, []database.Order{order})
+	if err != nil {
+		return OrderDetail{}, err
+	}
+	return details[0], nil
+}
+
+// GetOrderHandler maneja la consulta GetOrder
+type GetOrderHandler struct {
+	db database.Querier
+}
+
+// NewGetOrderHandler crea una nueva instancia del handler
+func NewGetOrderHandler(db database.Querier) *GetOrderHandler {
+	return &GetOrderHandler{
+		db: db,
+	}
+}
+
+// Handle implementa la interfaz QueryHandler
+func (h *GetOrderHandler) Handle(ctx context.Context, q GetOrderQuery) (OrderDetail, error) {
+	q.Queries = h.db
+	return q.Execute(ctx)
+}
@@ -0,0 +1,145 @@
+package queries
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+const (
+	// DefaultNotificationsLimit es la cantidad de notificaciones por página si
+	// no se indica
+	DefaultNotificationsLimit = 20
+	// MaxNotificationsLimit es la cantidad máxima de notificaciones por página
+	MaxNotificationsLimit = 100
+)
+
+// Notification es una notificación de un usuario
+type Notification struct {
+	ID      string    `json:"id"`
+	OrderID string    `json:"order_id"`
+	Message string    `json:"message"`
+	SentAt  time.Time `json:"sent_at"`
+	// ReadAt es nil mientras la notificación no se ha leído
+	ReadAt *time.Time `json:"read_at"`
+}
+
+// NotificationPage es una página de notificaciones. NextCursor es vacío en la
+// última página.
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+	// Unread es la cantidad total de notificaciones sin leer del usuario
+	Unread int64 `json:"unread"`
+}
+
+// GetUserNotificationsQuery representa la consulta para obtener las
+// notificaciones de un usuario, de la más reciente a la más antigua
+type GetUserNotificationsQuery struct {
+	UserID uuid.UUID `validate:"required"`
+	// Cursor es el NextCursor de la página anterior; vacío para la primera
+	Cursor     string
+	Limit      int `validate:"gte=1,lte=100"`
+	UnreadOnly bool
+	Queries    database.Querier `validate:"-"`
+}
+
+// Execute retorna una página de notificaciones
+func (q *GetUserNotificationsQuery) Execute(ctx context.Context) (NotificationPage, error) {
+	params := database.GetUserNotificationsParams{
+		UserID:     utils.ToPgUUID(q.UserID),
+		UnreadOnly: q.UnreadOnly,
+		// Se pide una fila extra para saber si hay otra página
+		RowLimit: int32(q.Limit + 1),
+	}
+	if q.Cursor != "" {
+		sentAt, id, err := decodeNotificationCursor(q.Cursor)
+		if err != nil {
+			return NotificationPage{}, err
+		}
+		params.CursorSentAt = utils.ToPgTimestamp(sentAt)
+		params.CursorID = utils.ToPgUUID(id)
+	}
+
+	rows, err := q.Queries.GetUserNotifications(ctx, params)
+	if err != nil {
+		return NotificationPage{}, err
+	}
+	unread, err := q.Queries.CountUnreadNotifications(ctx, utils.ToPgUUID(q.UserID))
+	if err != nil {
+		return NotificationPage{}, err
+	}
+
+	page := NotificationPage{
+		Notifications: make([]Notification, 0, min(len(rows), q.Limit)),
+		Unread:        unread,
+	}
+	for i, row := range rows {
+		if i == q.Limit {
+			last := rows[i-1]
+			page.NextCursor = encodeNotificationCursor(last.SentAt.Time, utils.FromPgUUID(last.ID))
+			break
+		}
+		notification := Notification{
+			ID:      utils.FromPgUUID(row.ID).String(),
+			OrderID: utils.FromPgUUID(row.OrderID).String(),
+			Message: row.Message,
+			SentAt:  row.SentAt.Time,
+		}
+		if row.ReadAt.Valid {
+			readAt := row.ReadAt.Time
+			notification.ReadAt = &readAt
+		}
+		page.Notifications = append(page.Notifications, notification)
+	}
+	return page, nil
+}
+
+// encodeNotificationCursor codifica la posición de una notificación como un
+// cursor opaco
+func encodeNotificationCursor(sentAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sentAt.Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeNotificationCursor(cursor string) (time.Time, uuid.UUID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	sentAtText, idText, ok := strings.Cut(string(data), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	sentAt, err := time.Parse(time.RFC3339Nano, sentAtText)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(idText)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	return sentAt, id, nil
+}
+
+// GetUserNotificationsHandler maneja la consulta GetUserNotifications
+type GetUserNotificationsHandler struct {
+	db database.Querier
+}
+
+// NewGetUserNotificationsHandler crea una nueva instancia del handler
+func NewGetUserNotificationsHandler(db database.Querier) *GetUserNotificationsHandler {
+	return &GetUserNotificationsHandler{
+		db: db,
+	}
+}
+
+// Handle implementa la interfaz QueryHandler
+func (h *GetUserNotificationsHandler) Handle(ctx context.Context, q GetUserNotificationsQuery) (NotificationPage, error) {
+	q.Queries = h.db
+	return q.Execute(ctx)
+}
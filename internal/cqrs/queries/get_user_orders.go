@@ -2,44 +2,138 @@ package queries
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/utils"
 )
 
+// OrderLine representa una línea de una orden
+type OrderLine struct {
+	ID              string      `json:"id"`
+	DishID          string      `json:"dish_id"`
+	DishName        string      `json:"dish_name"`
+	DishDescription string      `json:"dish_description"`
+	Quantity        int         `json:"quantity"`
+	UnitPrice       utils.Money `json:"unit_price"`
+	Subtotal        utils.Money `json:"subtotal"`
+	Notes           string      `json:"notes"`
+	PrepTimeMinutes int         `json:"prep_time_minutes"`
+}
+
+// OrderDetail representa una orden con sus líneas y el total calculado
+type OrderDetail struct {
+	ID        string      `json:"id"`
+	UserID    string      `json:"user_id"`
+	Status    string      `json:"status"`
+	Items     []OrderLine `json:"items"`
+	Total     utils.Money `json:"total"`
+	Currency  string      `json:"currency"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
 // GetUserOrdersQuery representa la consulta para obtener las órdenes de un usuario
 type GetUserOrdersQuery struct {
-	UserID  uuid.UUID
-	Queries database.Querier
+	UserID  uuid.UUID        `validate:"required"`
+	Queries database.Querier `validate:"-"`
 }
 
-// Execute implementa la interfaz Query
-func (q *GetUserOrdersQuery) Execute() (interface{}, error) {
-	ctx := context.Background()
+// Execute retorna las órdenes del usuario con sus líneas, de la más reciente
+// a la más antigua. Las lee de la proyección user_order_history, que guarda el
+// nombre y el precio de cada plato al momento de la orden.
+func (q *GetUserOrdersQuery) Execute(ctx context.Context) ([]OrderDetail, error) {
+	orders, err := q.Queries.GetUserOrderHistory(ctx, utils.ToPgUUID(q.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]OrderDetail, len(orders))
+	for i, order := range orders {
+		var items []OrderLine
+		if err := json.Unmarshal(order.Items, &items); err != nil {
+			return nil, err
+		}
+		total, err := utils.FromPgNumeric(order.Total)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = OrderDetail{
+			ID:        utils.FromPgUUID(order.OrderID).String(),
+			UserID:    utils.FromPgUUID(order.UserID).String(),
+			Status:    order.Status,
+			Items:     items,
+			Total:     total,
+			Currency:  total.Currency,
+			CreatedAt: order.CreatedAt.Time,
+			UpdatedAt: order.UpdatedAt.Time,
+		}
+	}
 
-	// Obtener las órdenes del usuario
-	orders, err := q.Queries.GetOrdersByUserId(ctx, utils.ToPgUUID(q.UserID))
+	return result, nil
+}
+
+// orderDetails agrega a cada orden sus líneas, consultadas en una sola query
+func orderDetails(ctx context.Context, db database.Querier, orders []database.Order) ([]OrderDetail, error) {
+	ids := make([]pgtype.UUID, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+
+	rows, err := db.GetOrderItemsByOrderIds(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convertir las órdenes a un formato más amigable
-	var result []map[string]interface{}
-	for _, order := range orders {
-		result = append(result, map[string]interface{}{
-			"id":               utils.FromPgUUID(order.ID).String(),
-			"user_id":          utils.FromPgUUID(order.UserID).String(),
-			"dish_id":          utils.FromPgUUID(order.DishID).String(),
-			"dish_name":        order.DishName,
-			"dish_description": order.DishDescription,
-			"dish_price":       order.DishPrice,
-			"status":           order.Status,
-			"created_at":       order.CreatedAt.Time,
-			"updated_at":       order.UpdatedAt.Time,
+	lines := make(map[uuid.UUID][]OrderLine, len(orders))
+	for _, row := range rows {
+		unitPrice, err := utils.FromPgNumeric(row.UnitPrice)
+		if err != nil {
+			return nil, err
+		}
+		subtotal, err := unitPrice.Mul(int64(row.Quantity))
+		if err != nil {
+			return nil, err
+		}
+		orderID := utils.FromPgUUID(row.OrderID)
+		lines[orderID] = append(lines[orderID], OrderLine{
+			ID:              utils.FromPgUUID(row.ID).String(),
+			DishID:          utils.FromPgUUID(row.DishID).String(),
+			DishName:        row.DishName,
+			DishDescription: row.DishDescription.String,
+			Quantity:        int(row.Quantity),
+			UnitPrice:       unitPrice,
+			Subtotal:        subtotal,
+			Notes:           row.Notes.String,
+			PrepTimeMinutes: int(row.DishPrepTimeMinutes),
 		})
 	}
 
+	// Convertir las órdenes a un formato más amigable
+	result := make([]OrderDetail, len(orders))
+	for i, order := range orders {
+		items := lines[utils.FromPgUUID(order.ID)]
+		total := utils.NewMoney(0, utils.DefaultCurrency)
+		for _, item := range items {
+			if total, err = total.Add(item.Subtotal); err != nil {
+				return nil, err
+			}
+		}
+		result[i] = OrderDetail{
+			ID:        utils.FromPgUUID(order.ID).String(),
+			UserID:    utils.FromPgUUID(order.UserID).String(),
+			Status:    order.Status,
+			Items:     items,
+			Total:     total,
+			Currency:  total.Currency,
+			CreatedAt: order.CreatedAt.Time,
+			UpdatedAt: order.UpdatedAt.Time,
+		}
+	}
+
 	return result, nil
 }
 
@@ -56,11 +150,7 @@ func NewGetUserOrdersHandler(db database.Querier) *GetUserOrdersHandler {
 }
 
 // Handle implementa la interfaz QueryHandler
-func (h *GetUserOrdersHandler) Handle(query Query) (interface{}, error) {
-	q, ok := query.(*GetUserOrdersQuery)
-	if !ok {
-		return nil, ErrInvalidQuery
-	}
+func (h *GetUserOrdersHandler) Handle(ctx context.Context, q GetUserOrdersQuery) ([]OrderDetail, error) {
 	q.Queries = h.db
-	return q.Execute()
+	return q.Execute(ctx)
 }
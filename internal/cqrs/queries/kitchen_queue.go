@@ -0,0 +1,76 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"github.com/rodrwan/themenu/internal/database"
+)
+
+// KitchenTicket es una orden activa en la cola de cocina
+type KitchenTicket struct {
+	OrderDetail
+	// PrepTimeMinutes es el mayor tiempo de preparación entre los platos de
+	// la orden, ya que se preparan en paralelo
+	PrepTimeMinutes int       `json:"prep_time_minutes"`
+	AgeSeconds      int64     `json:"age_seconds"`
+	DueAt           time.Time `json:"due_at"`
+	Overdue         bool      `json:"overdue"`
+}
+
+// KitchenQueueQuery representa la consulta de las órdenes activas, de la más
+// antigua a la más reciente
+type KitchenQueueQuery struct {
+	Queries database.Querier `validate:"-"`
+}
+
+// Execute retorna la cola de cocina
+func (q *KitchenQueueQuery) Execute(ctx context.Context) ([]KitchenTicket, error) {
+	orders, err := q.Queries.GetKitchenQueue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	details, err := orderDetails(ctx, q.Queries, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tickets := make([]KitchenTicket, len(details))
+	for i, detail := range details {
+		prepTime := 0
+		for _, item := range detail.Items {
+			prepTime = max(prepTime, item.PrepTimeMinutes)
+		}
+		dueAt := detail.CreatedAt.Add(time.Duration(prepTime) * time.Minute)
+
+		tickets[i] = KitchenTicket{
+			OrderDetail:     detail,
+			PrepTimeMinutes: prepTime,
+			AgeSeconds:      int64(now.Sub(detail.CreatedAt).Seconds()),
+			DueAt:           dueAt,
+			Overdue:         now.After(dueAt),
+		}
+	}
+
+	return tickets, nil
+}
+
+// KitchenQueueHandler maneja la consulta KitchenQueue
+type KitchenQueueHandler struct {
+	db database.Querier
+}
+
+// NewKitchenQueueHandler crea una nueva instancia del handler
+func NewKitchenQueueHandler(db database.Querier) *KitchenQueueHandler {
+	return &KitchenQueueHandler{
+		db: db,
+	}
+}
+
+// Handle implementa la interfaz QueryHandler
+func (h *KitchenQueueHandler) Handle(ctx context.Context, q KitchenQueueQuery) ([]KitchenTicket, error) {
+	q.Queries = h.db
+	return q.Execute(ctx)
+}
@@ -0,0 +1,183 @@
+package queries
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+const (
+	// DefaultListEventsLimit es la cantidad de eventos por página si no se
+	// indica
+	DefaultListEventsLimit = 100
+	// MaxListEventsLimit es la cantidad máxima de eventos por página
+	MaxListEventsLimit = 500
+)
+
+// StoredEvent es un evento del historial
+type StoredEvent struct {
+	// Position es el orden en que el historial recibió el evento
+	Position int64           `json:"position"`
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	Status   string          `json:"status"`
+	Payload  json.RawMessage `json:"payload"`
+	// AggregateType y AggregateID identifican la entidad a la que se refiere
+	// el evento; vacíos si no se pudo determinar
+	AggregateType string    `json:"aggregate_type,omitempty"`
+	AggregateID   string    `json:"aggregate_id,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+	RecordedAt    time.Time `json:"recorded_at"`
+}
+
+// EventPage es una página del historial de eventos. NextCursor es vacío en la
+// última página.
+type EventPage struct {
+	Events     []StoredEvent `json:"events"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// ListEventsQuery representa la consulta del historial de eventos en orden
+// cronológico. Los filtros en su valor cero no se aplican.
+type ListEventsQuery struct {
+	// Types contiene patrones de tipo de evento con la misma sintaxis que
+	// cqrs.EventFilter, por ejemplo "Order*"
+	Types       []string
+	AggregateID uuid.UUID
+	// From y To delimitan el instante del evento: [From, To)
+	From time.Time
+	To   time.Time `validate:"omitempty,gtfield=From"`
+	// Cursor es el NextCursor de la página anterior; vacío para la primera
+	Cursor  string
+	Limit   int              `validate:"gte=1,lte=500"`
+	Queries database.Querier `validate:"-"`
+}
+
+// Execute retorna una página del historial
+func (q *ListEventsQuery) Execute(ctx context.Context) (EventPage, error) {
+	params := database.ListEventsParams{
+		// Se pide una fila extra para saber si hay otra página
+		RowLimit: int32(q.Limit + 1),
+	}
+	for _, pattern := range q.Types {
+		params.TypePatterns = append(params.TypePatterns, likePattern(pattern))
+	}
+	if q.AggregateID != uuid.Nil {
+		params.AggregateID = utils.ToPgUUID(q.AggregateID)
+	}
+	if !q.From.IsZero() {
+		params.OccurredFrom = utils.ToPgTimestamp(q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		params.OccurredTo = utils.ToPgTimestamp(q.To.UTC())
+	}
+	if q.Cursor != "" {
+		occurredAt, position, err := decodeEventCursor(q.Cursor)
+		if err != nil {
+			return EventPage{}, err
+		}
+		params.CursorOccurredAt = utils.ToPgTimestamp(occurredAt)
+		params.CursorPosition = pgtype.Int8{Int64: position, Valid: true}
+	}
+
+	rows, err := q.Queries.ListEvents(ctx, params)
+	if err != nil {
+		return EventPage{}, err
+	}
+
+	page := EventPage{
+		Events: make([]StoredEvent, 0, min(len(rows), q.Limit)),
+	}
+	for i, row := range rows {
+		if i == q.Limit {
+			last := rows[i-1]
+			page.NextCursor = encodeEventCursor(last.OccurredAt.Time, last.Position)
+			break
+		}
+		event := StoredEvent{
+			Position:      row.Position,
+			ID:            utils.FromPgUUID(row.ID).String(),
+			Type:          row.EventType,
+			Status:        row.Status,
+			Payload:       json.RawMessage(row.Payload),
+			AggregateType: row.AggregateType.String,
+			Timestamp:     row.OccurredAt.Time,
+			RecordedAt:    row.RecordedAt.Time,
+		}
+		if row.AggregateID.Valid {
+			event.AggregateID = utils.FromPgUUID(row.AggregateID).String()
+		}
+		page.Events = append(page.Events, event)
+	}
+	return page, nil
+}
+
+// likePattern convierte un patrón de tipo de evento en un patrón LIKE: '*' y
+// ".*" coinciden con cualquier secuencia y el resto se compara literalmente
+func likePattern(pattern string) string {
+	pattern = strings.ReplaceAll(pattern, ".*", "*")
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteRune('%')
+		case '%', '_', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// encodeEventCursor codifica la posición de un evento como un cursor opaco
+func encodeEventCursor(occurredAt time.Time, position int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(occurredAt.Format(time.RFC3339Nano) + "|" + strconv.FormatInt(position, 10)))
+}
+
+func decodeEventCursor(cursor string) (time.Time, int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	occurredAtText, positionText, ok := strings.Cut(string(data), "|")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	occurredAt, err := time.Parse(time.RFC3339Nano, occurredAtText)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	position, err := strconv.ParseInt(positionText, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return occurredAt, position, nil
+}
+
+// ListEventsHandler maneja la consulta ListEvents
+type ListEventsHandler struct {
+	db database.Querier
+}
+
+// NewListEventsHandler crea una nueva instancia del handler
+func NewListEventsHandler(db database.Querier) *ListEventsHandler {
+	return &ListEventsHandler{
+		db: db,
+	}
+}
+
+// Handle implementa la interfaz QueryHandler
+func (h *ListEventsHandler) Handle(ctx context.Context, q ListEventsQuery) (EventPage, error) {
+	q.Queries = h.db
+	return q.Execute(ctx)
+}
@@ -0,0 +1,78 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+const (
+	// DefaultListOrdersLimit es la cantidad de órdenes por página si no se indica
+	DefaultListOrdersLimit = 50
+	// MaxListOrdersLimit es la cantidad máxima de órdenes por página
+	MaxListOrdersLimit = 200
+)
+
+// ListOrdersQuery representa la consulta para listar órdenes con filtros. Los
+// filtros en su valor cero no se aplican.
+type ListOrdersQuery struct {
+	Statuses []string `validate:"dive,oneof=received confirmed preparing served cancelled"`
+	UserID   uuid.UUID
+	DishID   uuid.UUID
+	// From y To delimitan la fecha de creación: [From, To)
+	From    time.Time
+	To      time.Time        `validate:"omitempty,gtfield=From"`
+	Limit   int              `validate:"gte=1,lte=200"`
+	Offset  int              `validate:"gte=0"`
+	Queries database.Querier `validate:"-"`
+}
+
+// Execute retorna las órdenes que cumplen los filtros, de la más reciente a
+// la más antigua
+func (q *ListOrdersQuery) Execute(ctx context.Context) ([]OrderDetail, error) {
+	params := database.ListOrdersParams{
+		Statuses:  q.Statuses,
+		RowLimit:  int32(q.Limit),
+		RowOffset: int32(q.Offset),
+	}
+	if q.UserID != uuid.Nil {
+		params.UserID = utils.ToPgUUID(q.UserID)
+	}
+	if q.DishID != uuid.Nil {
+		params.DishID = utils.ToPgUUID(q.DishID)
+	}
+	if !q.From.IsZero() {
+		params.CreatedFrom = utils.ToPgTimestamp(q.From)
+	}
+	if !q.To.IsZero() {
+		params.CreatedTo = utils.ToPgTimestamp(q.To)
+	}
+
+	orders, err := q.Queries.ListOrders(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return orderDetails(ctx, q.Queries, orders)
+}
+
+// ListOrdersHandler maneja la consulta ListOrders
+type ListOrdersHandler struct {
+	db database.Querier
+}
+
+// NewListOrdersHandler crea una nueva instancia del handler
+func NewListOrdersHandler(db database.Querier) *ListOrdersHandler {
+	return &ListOrdersHandler{
+		db: db,
+	}
+}
+
+// Handle implementa la interfaz QueryHandler
+func (h *ListOrdersHandler) Handle(ctx context.Context, q ListOrdersQuery) ([]OrderDetail, error) {
+	q.Queries = h.db
+	return q.Execute(ctx)
+}
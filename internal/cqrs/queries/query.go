@@ -1,17 +1,20 @@
 package queries
 
-// Query representa una operación de lectura que no modifica el estado
-type Query interface {
-	Execute() (interface{}, error)
-}
+import (
+	"context"
+
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
+)
 
-// QueryHandler maneja la ejecución de una consulta específica
-type QueryHandler interface {
-	Handle(query Query) (interface{}, error)
+// QueryHandler maneja la ejecución de una consulta de tipo Q y retorna un
+// resultado de tipo R
+type QueryHandler[Q any, R any] interface {
+	Handle(ctx context.Context, query Q) (R, error)
 }
 
-// QueryDispatcher es el bus de consultas que distribuye las consultas a sus handlers
+// QueryDispatcher es el bus de consultas que distribuye las consultas a sus
+// handlers. Se usa a través de Dispatch, que retorna el resultado tipado.
 type QueryDispatcher interface {
-	Dispatch(query Query) (interface{}, error)
-	Register(queryType string, handler QueryHandler)
+	Use(middlewares ...pipeline.Middleware)
+	dispatch(ctx context.Context, query any) (any, error)
 }
@@ -0,0 +1,56 @@
+package cqrs
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// pubSubChannel es el canal de Redis Pub/Sub donde se publican los eventos
+const pubSubChannel = "events"
+
+// pubSubTransport distribuye eventos mediante Redis Pub/Sub. Los eventos
+// publicados mientras un servicio está desconectado se pierden.
+type pubSubTransport struct {
+	client *redis.Client
+}
+
+func newPubSubTransport(client *redis.Client) *pubSubTransport {
+	return &pubSubTransport{client: client}
+}
+
+func (t *pubSubTransport) publish(ctx context.Context, payload []byte) error {
+	return t.client.Publish(ctx, pubSubChannel, string(payload)).Err()
+}
+
+// consume escucha los eventos publicados en Redis. Pub/Sub no permite
+// reentregar, por lo que un evento que dispatch no pudo entregar se pierde.
+func (t *pubSubTransport) consume(ctx context.Context, dispatch func(Event) bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			pubsub := t.client.Subscribe(ctx, pubSubChannel)
+			ch := pubsub.Channel(
+				redis.WithChannelSize(RedisBufferSize),
+			)
+
+			for msg := range ch {
+				var event Event
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					log.Printf("Error al deserializar evento: %v", err)
+					continue
+				}
+				dispatch(event)
+			}
+
+			// Si llegamos aquí, la conexión se cerró
+			log.Println("Conexión Redis cerrada, intentando reconectar...")
+			time.Sleep(time.Second) // Esperar antes de reconectar
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package cqrs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// StreamKey es la clave del stream de Redis donde se almacenan los eventos
+	StreamKey = "events:stream"
+	// StreamMaxLen define el largo aproximado máximo del stream
+	StreamMaxLen = 100000
+	// StreamReadCount define cuántas entradas se leen por llamada a XREADGROUP
+	StreamReadCount = 100
+	// StreamBlockTimeout define cuánto se bloquea XREADGROUP esperando entradas nuevas
+	StreamBlockTimeout = 5 * time.Second
+	// StreamReclaimInterval define cada cuánto se buscan entradas pendientes abandonadas
+	StreamReclaimInterval = 30 * time.Second
+	// StreamReclaimMinIdle define cuánto tiempo debe estar pendiente una entrada
+	// sin confirmarse antes de que otro consumidor la reclame
+	StreamReclaimMinIdle = time.Minute
+)
+
+// streamTransport distribuye eventos mediante Redis Streams. Cada servicio
+// lee con su propio grupo de consumidores, confirma (XACK) los eventos
+// entregados y al reiniciar continúa desde el último ID confirmado.
+type streamTransport struct {
+	client     *redis.Client
+	group      string
+	consumer   string
+	groupStart string
+}
+
+func newStreamTransport(client *redis.Client, group, consumer, groupStart string) *streamTransport {
+	return &streamTransport{
+		client:     client,
+		group:      group,
+		consumer:   consumer,
+		groupStart: groupStart,
+	}
+}
+
+// streamGroupFromEnv retorna el grupo de consumidores del servicio. Por
+// defecto se usa el nombre del binario para que cada servicio reciba todos
+// los eventos.
+func streamGroupFromEnv() string {
+	if group := os.Getenv("EVENT_BUS_GROUP"); group != "" {
+		return group
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// streamGroupStartFromEnv retorna el ID desde el que lee un grupo nuevo. Por
+// defecto es "0": al crearse el grupo se procesa todo lo que conserva el
+// stream, incluidos los eventos publicados antes de que el servicio arrancara
+// por primera vez. Con "$" el grupo sólo recibe los eventos publicados después
+// de su creación.
+func streamGroupStartFromEnv() string {
+	if start := os.Getenv("EVENT_BUS_GROUP_START"); start != "" {
+		return start
+	}
+	return "0"
+}
+
+// streamConsumerFromEnv retorna el nombre del consumidor dentro del grupo
+func streamConsumerFromEnv() string {
+	if consumer := os.Getenv("EVENT_BUS_CONSUMER"); consumer != "" {
+		return consumer
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "consumer"
+	}
+	return hostname
+}
+
+func (t *streamTransport) publish(ctx context.Context, payload []byte) error {
+	return t.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: StreamKey,
+		MaxLen: StreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": string(payload)},
+	}).Err()
+}
+
+// ensureGroup crea el grupo de consumidores si todavía no existe, leyendo
+// desde groupStart. Un grupo existente conserva su posición.
+func (t *streamTransport) ensureGroup(ctx context.Context) error {
+	err := t.client.XGroupCreateMkStream(ctx, StreamKey, t.group, t.groupStart).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// consume lee el stream con XREADGROUP hasta que se cancela el contexto
+func (t *streamTransport) consume(ctx context.Context, dispatch func(Event) bool) {
+	for {
+		if err := t.ensureGroup(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error al crear el grupo %s en Redis: %v", t.group, err)
+			time.Sleep(time.Second)
+			continue
+		}
+		break
+	}
+
+	log.Printf("Consumiendo %s como %s/%s", StreamKey, t.group, t.consumer)
+
+	// Reprocesar primero las entradas que este consumidor leyó pero no
+	// alcanzó a confirmar antes de reiniciarse
+	t.read(ctx, "0", dispatch)
+
+	go t.reclaim(ctx, dispatch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			t.read(ctx, ">", dispatch)
+		}
+	}
+}
+
+// read lee entradas del stream a partir de start. Con start ">" se leen
+// entradas nuevas; con "0" se recorre la lista de pendientes del consumidor.
+func (t *streamTransport) read(ctx context.Context, start string, dispatch func(Event) bool) {
+	for {
+		block := StreamBlockTimeout
+		if start != ">" {
+			block = -1 // las pendientes se leen sin bloquear
+		}
+
+		streams, err := t.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    t.group,
+			Consumer: t.consumer,
+			Streams:  []string{StreamKey, start},
+			Count:    StreamReadCount,
+			Block:    block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error al leer el stream %s: %v", StreamKey, err)
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				_ = t.ensureGroup(ctx)
+			}
+			time.Sleep(time.Second)
+			return
+		}
+
+		read := 0
+		for _, stream := range streams {
+			read += len(stream.Messages)
+			lastID := start
+			for _, msg := range stream.Messages {
+				t.handle(ctx, msg, dispatch)
+				lastID = msg.ID
+			}
+			if start != ">" {
+				start = lastID
+			}
+		}
+
+		// Las entradas nuevas se leen de a un lote; las pendientes se
+		// recorren hasta vaciar la lista
+		if start == ">" || read == 0 {
+			return
+		}
+	}
+}
+
+// reclaim toma periódicamente las entradas pendientes de consumidores del
+// mismo grupo que dejaron de confirmarlas (por ejemplo, una instancia caída)
+func (t *streamTransport) reclaim(ctx context.Context, dispatch func(Event) bool) {
+	ticker := time.NewTicker(StreamReclaimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := "0-0"
+			for {
+				msgs, next, err := t.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+					Stream:   StreamKey,
+					Group:    t.group,
+					Consumer: t.consumer,
+					MinIdle:  StreamReclaimMinIdle,
+					Start:    start,
+					Count:    StreamReadCount,
+				}).Result()
+				if err != nil {
+					if ctx.Err() == nil {
+						log.Printf("Error al reclamar eventos pendientes: %v", err)
+					}
+					break
+				}
+				if len(msgs) > 0 {
+					log.Printf("Reclamados %d eventos pendientes", len(msgs))
+				}
+				for _, msg := range msgs {
+					t.handle(ctx, msg, dispatch)
+				}
+				if next == "0-0" || next == "" {
+					break
+				}
+				start = next
+			}
+		}
+	}
+}
+
+// handle entrega una entrada del stream y la confirma si fue procesada
+func (t *streamTransport) handle(ctx context.Context, msg redis.XMessage, dispatch func(Event) bool) {
+	raw, _ := msg.Values["event"].(string)
+
+	var event Event
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		// Un evento corrupto no se podrá procesar nunca; se confirma para
+		// que no quede pendiente para siempre
+		log.Printf("Error al deserializar evento %s: %v", msg.ID, err)
+		t.ack(ctx, msg.ID)
+		return
+	}
+
+	if !dispatch(event) {
+		// Queda pendiente y se volverá a entregar al reclamarla
+		return
+	}
+	t.ack(ctx, msg.ID)
+}
+
+func (t *streamTransport) ack(ctx context.Context, id string) {
+	if err := t.client.XAck(ctx, StreamKey, t.group, id).Err(); err != nil {
+		log.Printf("Error al confirmar evento %s: %v", id, err)
+	}
+}
@@ -19,28 +19,97 @@ type Dish struct {
 	UpdatedAt       pgtype.Timestamp `db:"updated_at" json:"updated_at"`
 }
 
+type Event struct {
+	Position      int64            `db:"position" json:"position"`
+	ID            pgtype.UUID      `db:"id" json:"id"`
+	EventType     string           `db:"event_type" json:"event_type"`
+	Status        string           `db:"status" json:"status"`
+	AggregateType pgtype.Text      `db:"aggregate_type" json:"aggregate_type"`
+	AggregateID   pgtype.UUID      `db:"aggregate_id" json:"aggregate_id"`
+	Payload       []byte           `db:"payload" json:"payload"`
+	OccurredAt    pgtype.Timestamp `db:"occurred_at" json:"occurred_at"`
+	RecordedAt    pgtype.Timestamp `db:"recorded_at" json:"recorded_at"`
+	TransactionID int64            `db:"transaction_id" json:"transaction_id"`
+}
+
+type IdempotencyKey struct {
+	UserID          pgtype.UUID      `db:"user_id" json:"user_id"`
+	Key             string           `db:"key" json:"key"`
+	Fingerprint     string           `db:"fingerprint" json:"fingerprint"`
+	StatusCode      pgtype.Int4      `db:"status_code" json:"status_code"`
+	ResponseBody    []byte           `db:"response_body" json:"response_body"`
+	ResponseHeaders []byte           `db:"response_headers" json:"response_headers"`
+	CreatedAt       pgtype.Timestamp `db:"created_at" json:"created_at"`
+	ExpiresAt       pgtype.Timestamp `db:"expires_at" json:"expires_at"`
+}
+
+type MenuByDate struct {
+	DishID          pgtype.UUID      `db:"dish_id" json:"dish_id"`
+	AvailableOn     pgtype.Date      `db:"available_on" json:"available_on"`
+	Name            string           `db:"name" json:"name"`
+	Description     string           `db:"description" json:"description"`
+	Price           pgtype.Numeric   `db:"price" json:"price"`
+	PrepTimeMinutes int32            `db:"prep_time_minutes" json:"prep_time_minutes"`
+	Version         pgtype.Timestamp `db:"version" json:"version"`
+}
+
 type Notification struct {
 	ID      pgtype.UUID      `db:"id" json:"id"`
 	UserID  pgtype.UUID      `db:"user_id" json:"user_id"`
 	OrderID pgtype.UUID      `db:"order_id" json:"order_id"`
 	Message string           `db:"message" json:"message"`
 	SentAt  pgtype.Timestamp `db:"sent_at" json:"sent_at"`
+	ReadAt  pgtype.Timestamp `db:"read_at" json:"read_at"`
+}
+
+type NotificationDelivery struct {
+	NotificationID pgtype.UUID      `db:"notification_id" json:"notification_id"`
+	Channel        string           `db:"channel" json:"channel"`
+	DeliveredAt    pgtype.Timestamp `db:"delivered_at" json:"delivered_at"`
 }
 
 type Order struct {
 	ID        pgtype.UUID      `db:"id" json:"id"`
 	UserID    pgtype.UUID      `db:"user_id" json:"user_id"`
-	DishID    pgtype.UUID      `db:"dish_id" json:"dish_id"`
 	Status    string           `db:"status" json:"status"`
 	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
 	UpdatedAt pgtype.Timestamp `db:"updated_at" json:"updated_at"`
 }
 
+type OrderItem struct {
+	ID        pgtype.UUID      `db:"id" json:"id"`
+	OrderID   pgtype.UUID      `db:"order_id" json:"order_id"`
+	DishID    pgtype.UUID      `db:"dish_id" json:"dish_id"`
+	Quantity  int32            `db:"quantity" json:"quantity"`
+	UnitPrice pgtype.Numeric   `db:"unit_price" json:"unit_price"`
+	Notes     pgtype.Text      `db:"notes" json:"notes"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
+type Outbox struct {
+	ID            pgtype.UUID      `db:"id" json:"id"`
+	EventType     string           `db:"event_type" json:"event_type"`
+	Status        string           `db:"status" json:"status"`
+	Payload       []byte           `db:"payload" json:"payload"`
+	CreatedAt     pgtype.Timestamp `db:"created_at" json:"created_at"`
+	Attempts      int32            `db:"attempts" json:"attempts"`
+	LastError     pgtype.Text      `db:"last_error" json:"last_error"`
+	NextAttemptAt pgtype.Timestamp `db:"next_attempt_at" json:"next_attempt_at"`
+	DeliveredAt   pgtype.Timestamp `db:"delivered_at" json:"delivered_at"`
+}
+
 type Permission struct {
 	ID   pgtype.UUID `db:"id" json:"id"`
 	Name string      `db:"name" json:"name"`
 }
 
+type ProjectionCheckpoint struct {
+	Name          string           `db:"name" json:"name"`
+	Position      int64            `db:"position" json:"position"`
+	UpdatedAt     pgtype.Timestamp `db:"updated_at" json:"updated_at"`
+	TransactionID int64            `db:"transaction_id" json:"transaction_id"`
+}
+
 type Role struct {
 	ID   pgtype.UUID `db:"id" json:"id"`
 	Name string      `db:"name" json:"name"`
@@ -52,13 +121,59 @@ type RolePermission struct {
 }
 
 type User struct {
-	ID        pgtype.UUID      `db:"id" json:"id"`
-	Name      string           `db:"name" json:"name"`
-	Email     string           `db:"email" json:"email"`
+	ID           pgtype.UUID      `db:"id" json:"id"`
+	Name         string           `db:"name" json:"name"`
+	Email        string           `db:"email" json:"email"`
+	CreatedAt    pgtype.Timestamp `db:"created_at" json:"created_at"`
+	PasswordHash pgtype.Text      `db:"password_hash" json:"password_hash"`
+}
+
+type UserOrderHistory struct {
+	OrderID   pgtype.UUID      `db:"order_id" json:"order_id"`
+	UserID    pgtype.UUID      `db:"user_id" json:"user_id"`
+	Status    string           `db:"status" json:"status"`
+	Total     pgtype.Numeric   `db:"total" json:"total"`
+	Items     []byte           `db:"items" json:"items"`
 	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamp `db:"updated_at" json:"updated_at"`
+	Version   pgtype.Timestamp `db:"version" json:"version"`
 }
 
 type UserRole struct {
 	UserID pgtype.UUID `db:"user_id" json:"user_id"`
 	RoleID pgtype.UUID `db:"role_id" json:"role_id"`
 }
+
+type WebhookDelivery struct {
+	ID             pgtype.UUID      `db:"id" json:"id"`
+	SubscriptionID pgtype.UUID      `db:"subscription_id" json:"subscription_id"`
+	EventID        pgtype.UUID      `db:"event_id" json:"event_id"`
+	EventType      string           `db:"event_type" json:"event_type"`
+	Payload        []byte           `db:"payload" json:"payload"`
+	Status         string           `db:"status" json:"status"`
+	Attempts       int32            `db:"attempts" json:"attempts"`
+	LastStatusCode pgtype.Int4      `db:"last_status_code" json:"last_status_code"`
+	LastError      pgtype.Text      `db:"last_error" json:"last_error"`
+	NextAttemptAt  pgtype.Timestamp `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt      pgtype.Timestamp `db:"created_at" json:"created_at"`
+	DeliveredAt    pgtype.Timestamp `db:"delivered_at" json:"delivered_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID          int64            `db:"id" json:"id"`
+	DeliveryID  pgtype.UUID      `db:"delivery_id" json:"delivery_id"`
+	StatusCode  pgtype.Int4      `db:"status_code" json:"status_code"`
+	Error       pgtype.Text      `db:"error" json:"error"`
+	DurationMs  int32            `db:"duration_ms" json:"duration_ms"`
+	AttemptedAt pgtype.Timestamp `db:"attempted_at" json:"attempted_at"`
+}
+
+type WebhookSubscription struct {
+	ID         pgtype.UUID      `db:"id" json:"id"`
+	Url        string           `db:"url" json:"url"`
+	EventTypes []string         `db:"event_types" json:"event_types"`
+	Secret     string           `db:"secret" json:"secret"`
+	Active     bool             `db:"active" json:"active"`
+	CreatedAt  pgtype.Timestamp `db:"created_at" json:"created_at"`
+	UpdatedAt  pgtype.Timestamp `db:"updated_at" json:"updated_at"`
+}
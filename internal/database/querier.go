@@ -11,30 +11,131 @@ import (
 )
 
 type Querier interface {
+	// Un evento reentregado por el EventBus o republicado no se duplica
+	AppendEvent(ctx context.Context, arg AppendEventParams) (int64, error)
+	// Asigna al usuario el rol con el nombre indicado. Retorna 0 filas si el rol
+	// no existe (los roles se crean en seed.sql).
+	AssignUserRole(ctx context.Context, arg AssignUserRoleParams) (int64, error)
+	// Toma un lote de entregas pendientes de suscripciones activas y posterga su
+	// siguiente intento por lease_seconds, de modo que otra instancia del worker
+	// no las envíe mientras están en curso.
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error)
+	// Copia los platos de un rango de fechas desplazándolos offset_days días. Los
+	// platos que ya existen con el mismo nombre en la fecha destino se omiten,
+	// por lo que repetir la copia no duplica platos.
+	CloneDishes(ctx context.Context, arg CloneDishesParams) ([]Dish, error)
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error
+	CountUnreadNotifications(ctx context.Context, userID pgtype.UUID) (int64, error)
 	CreateDish(ctx context.Context, arg CreateDishParams) (Dish, error)
 	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
 	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error)
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	// Un evento reentregado por el EventBus no genera una segunda entrega
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (int64, error)
+	CreateWebhookDeliveryAttempt(ctx context.Context, arg CreateWebhookDeliveryAttemptParams) error
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error)
+	DeleteAllMenuDishes(ctx context.Context) error
+	DeleteAllUserOrderHistory(ctx context.Context) error
 	DeleteDish(ctx context.Context, id pgtype.UUID) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	DeleteMenuDish(ctx context.Context, dishID pgtype.UUID) error
 	DeleteUser(ctx context.Context, id pgtype.UUID) error
+	DeleteUserOrderHistory(ctx context.Context, orderID pgtype.UUID) error
+	DeleteWebhookSubscription(ctx context.Context, id pgtype.UUID) (int64, error)
+	EnsureProjectionCheckpoint(ctx context.Context, name string) error
 	GetDish(ctx context.Context, id pgtype.UUID) (Dish, error)
 	GetDishByName(ctx context.Context, name string) (Dish, error)
 	GetDishesByDate(ctx context.Context, availableOn pgtype.Date) ([]Dish, error)
+	GetDishesByDateRange(ctx context.Context, arg GetDishesByDateRangeParams) ([]Dish, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	// Órdenes activas, de la más antigua a la más reciente
+	GetKitchenQueue(ctx context.Context) ([]Order, error)
+	GetMenuByDate(ctx context.Context, availableOn pgtype.Date) ([]MenuByDate, error)
+	// Canales por los que ya se entregó la notificación
+	GetNotificationChannels(ctx context.Context, notificationID pgtype.UUID) ([]string, error)
 	GetNotificationsByUserId(ctx context.Context, userID pgtype.UUID) ([]Notification, error)
 	GetOrder(ctx context.Context, id pgtype.UUID) (Order, error)
+	GetOrderForUpdate(ctx context.Context, id pgtype.UUID) (Order, error)
+	GetOrderItemsByOrderIds(ctx context.Context, orderIds []pgtype.UUID) ([]GetOrderItemsByOrderIdsRow, error)
 	GetOrdersByDishId(ctx context.Context, dishID pgtype.UUID) ([]Order, error)
 	GetOrdersByStatus(ctx context.Context, status string) ([]Order, error)
-	GetOrdersByUserId(ctx context.Context, userID pgtype.UUID) ([]GetOrdersByUserIdRow, error)
+	GetOrdersByUserId(ctx context.Context, userID pgtype.UUID) ([]Order, error)
+	GetPendingOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error)
 	GetPermissions(ctx context.Context) ([]Permission, error)
 	GetRolePermissions(ctx context.Context) ([]RolePermission, error)
 	GetRoles(ctx context.Context) ([]Role, error)
 	GetUser(ctx context.Context, id pgtype.UUID) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
+	// Notificaciones de un usuario de la más reciente a la más antigua. El cursor
+	// es (sent_at, id) de la última notificación de la página anterior; en NULL
+	// se parte desde la más reciente.
+	GetUserNotifications(ctx context.Context, arg GetUserNotificationsParams) ([]Notification, error)
+	GetUserOrderHistory(ctx context.Context, userID pgtype.UUID) ([]UserOrderHistory, error)
+	GetUserPermissions(ctx context.Context, userID pgtype.UUID) ([]string, error)
+	GetUserRoleNames(ctx context.Context, userID pgtype.UUID) ([]string, error)
 	GetUserRoles(ctx context.Context) ([]UserRole, error)
+	GetWebhookDelivery(ctx context.Context, id pgtype.UUID) (WebhookDelivery, error)
+	GetWebhookSubscription(ctx context.Context, id pgtype.UUID) (WebhookSubscription, error)
 	ListDishes(ctx context.Context) ([]Dish, error)
+	// Historial de eventos en orden cronológico. type_patterns son patrones LIKE
+	// del tipo de evento; los filtros en NULL no se aplican. El cursor es
+	// (occurred_at, position) del último evento de la página anterior.
+	ListEvents(ctx context.Context, arg ListEventsParams) ([]Event, error)
+	// Eventos del historial posteriores a un checkpoint (transaction_id,
+	// position), en ese orden. Sólo se leen los eventos de transacciones
+	// anteriores a la más antigua todavía activa: esas transacciones ya
+	// terminaron, por lo que ningún evento puede aparecer después antes del
+	// checkpoint. type_patterns son patrones LIKE del tipo de evento.
+	ListEventsAfter(ctx context.Context, arg ListEventsAfterParams) ([]Event, error)
+	// Los filtros en NULL no se aplican. El rango de fechas es [created_from, created_to).
+	ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error)
+	// Entregas de la más reciente a la más antigua, opcionalmente de una
+	// suscripción y con un estado. El cursor es (created_at, id) de la última
+	// entrega de la página anterior.
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID pgtype.UUID) ([]WebhookDeliveryAttempt, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	// Suscripciones activas que escuchan el tipo de evento
+	ListWebhookSubscriptionsForEvent(ctx context.Context, eventType string) ([]WebhookSubscription, error)
+	// Bloquea el checkpoint hasta el fin de la transacción, de modo que dos
+	// instancias no apliquen a la vez los mismos eventos
+	LockProjectionCheckpoint(ctx context.Context, name string) (LockProjectionCheckpointRow, error)
+	MarkAllNotificationsRead(ctx context.Context, userID pgtype.UUID) ([]pgtype.UUID, error)
+	// Una notificación ya leída conserva su read_at
+	MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (Notification, error)
+	MarkOutboxEventDelivered(ctx context.Context, id pgtype.UUID) error
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error
+	MarkWebhookDeliveryDelivered(ctx context.Context, arg MarkWebhookDeliveryDeliveredParams) error
+	// status queda en 'pending' para reintentar en next_attempt_at o pasa a 'dead'
+	MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error
+	RecordNotificationDelivery(ctx context.Context, arg RecordNotificationDeliveryParams) error
+	// Vuelve a encolar una entrega con todos sus intentos disponibles
+	RedeliverWebhookDelivery(ctx context.Context, id pgtype.UUID) (WebhookDelivery, error)
+	// Reserva la llave para un request nuevo. Si la llave existe y no ha expirado
+	// no modifica nada y retorna 0 filas; una llave expirada se reutiliza. Una
+	// reserva sin respuesta (status_code NULL) de hace más de lease_seconds se
+	// considera abandonada, por ejemplo porque el writer se cayó, y también se
+	// reutiliza.
+	ReserveIdempotencyKey(ctx context.Context, arg ReserveIdempotencyKeyParams) (int64, error)
+	SaveProjectionCheckpoint(ctx context.Context, arg SaveProjectionCheckpointParams) error
+	// Carga menu_by_date desde la tabla dishes, igual que la migración que la
+	// creó. version es updated_at, por lo que los eventos anteriores al estado
+	// actual del plato no lo sobrescriben.
+	SeedMenuByDate(ctx context.Context) error
+	// Carga user_order_history desde orders y order_items, igual que la migración
+	// que la creó. version es updated_at, por lo que los eventos anteriores al
+	// estado actual de la orden no la sobrescriben.
+	SeedUserOrderHistory(ctx context.Context) error
+	SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error)
 	UpdateDish(ctx context.Context, arg UpdateDishParams) (Dish, error)
 	UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (WebhookSubscription, error)
+	UpsertMenuDish(ctx context.Context, arg UpsertMenuDishParams) error
+	UpsertUserOrderHistory(ctx context.Context, arg UpsertUserOrderHistoryParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -11,6 +11,215 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const appendEvent = `-- name: AppendEvent :execrows
+INSERT INTO events (id, event_type, status, aggregate_type, aggregate_id, payload, occurred_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (id) DO NOTHING
+`
+
+type AppendEventParams struct {
+	ID            pgtype.UUID      `db:"id" json:"id"`
+	EventType     string           `db:"event_type" json:"event_type"`
+	Status        string           `db:"status" json:"status"`
+	AggregateType pgtype.Text      `db:"aggregate_type" json:"aggregate_type"`
+	AggregateID   pgtype.UUID      `db:"aggregate_id" json:"aggregate_id"`
+	Payload       []byte           `db:"payload" json:"payload"`
+	OccurredAt    pgtype.Timestamp `db:"occurred_at" json:"occurred_at"`
+}
+
+// Un evento reentregado por el EventBus o republicado no se duplica
+func (q *Queries) AppendEvent(ctx context.Context, arg AppendEventParams) (int64, error) {
+	result, err := q.db.Exec(ctx, appendEvent,
+		arg.ID,
+		arg.EventType,
+		arg.Status,
+		arg.AggregateType,
+		arg.AggregateID,
+		arg.Payload,
+		arg.OccurredAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const assignUserRole = `-- name: AssignUserRole :execrows
+INSERT INTO user_roles (user_id, role_id)
+SELECT $1, r.id
+FROM roles r
+WHERE r.name = $2
+ON CONFLICT DO NOTHING
+`
+
+type AssignUserRoleParams struct {
+	UserID   pgtype.UUID `db:"user_id" json:"user_id"`
+	RoleName string      `db:"role_name" json:"role_name"`
+}
+
+// Asigna al usuario el rol con el nombre indicado. Retorna 0 filas si el rol
+// no existe (los roles se crean en seed.sql).
+func (q *Queries) AssignUserRole(ctx context.Context, arg AssignUserRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, assignUserRole, arg.UserID, arg.RoleName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET next_attempt_at = now() + make_interval(secs => $1::float8)
+FROM webhook_subscriptions s
+WHERE s.id = d.subscription_id
+  AND d.id IN (
+    SELECT pd.id FROM webhook_deliveries pd
+    JOIN webhook_subscriptions ps ON ps.id = pd.subscription_id
+    WHERE pd.status = 'pending' AND pd.next_attempt_at <= now() AND ps.active
+    ORDER BY pd.next_attempt_at
+    LIMIT $2
+    FOR UPDATE OF pd SKIP LOCKED
+  )
+RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.attempts, s.url, s.secret
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	LeaseSeconds float64 `db:"lease_seconds" json:"lease_seconds"`
+	BatchSize    int32   `db:"batch_size" json:"batch_size"`
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	ID             pgtype.UUID `db:"id" json:"id"`
+	SubscriptionID pgtype.UUID `db:"subscription_id" json:"subscription_id"`
+	EventID        pgtype.UUID `db:"event_id" json:"event_id"`
+	EventType      string      `db:"event_type" json:"event_type"`
+	Payload        []byte      `db:"payload" json:"payload"`
+	Attempts       int32       `db:"attempts" json:"attempts"`
+	Url            string      `db:"url" json:"url"`
+	Secret         string      `db:"secret" json:"secret"`
+}
+
+// Toma un lote de entregas pendientes de suscripciones activas y posterga su
+// siguiente intento por lease_seconds, de modo que otra instancia del worker
+// no las envíe mientras están en curso.
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimWebhookDeliveries, arg.LeaseSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimWebhookDeliveriesRow
+	for rows.Next() {
+		var i ClaimWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const cloneDishes = `-- name: CloneDishes :many
+INSERT INTO dishes (id, name, description, price, prep_time_minutes, available_on)
+SELECT gen_random_uuid(), d.name, d.description, d.price, d.prep_time_minutes, d.available_on + $1::int
+FROM dishes d
+WHERE d.available_on BETWEEN $2 AND $3
+  AND NOT EXISTS (
+    SELECT 1 FROM dishes t
+    WHERE t.name = d.name AND t.available_on = d.available_on + $1::int
+  )
+RETURNING id, name, description, price, prep_time_minutes, available_on, created_at, updated_at
+`
+
+type CloneDishesParams struct {
+	OffsetDays int32       `db:"offset_days" json:"offset_days"`
+	FromDate   pgtype.Date `db:"from_date" json:"from_date"`
+	ToDate     pgtype.Date `db:"to_date" json:"to_date"`
+}
+
+// Copia los platos de un rango de fechas desplazándolos offset_days días. Los
+// platos que ya existen con el mismo nombre en la fecha destino se omiten,
+// por lo que repetir la copia no duplica platos.
+func (q *Queries) CloneDishes(ctx context.Context, arg CloneDishesParams) ([]Dish, error) {
+	rows, err := q.db.Query(ctx, cloneDishes, arg.OffsetDays, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Dish
+	for rows.Next() {
+		var i Dish
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.PrepTimeMinutes,
+			&i.AvailableOn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $3,
+    response_body = $4,
+    response_headers = $5
+WHERE user_id = $1 AND key = $2
+`
+
+type CompleteIdempotencyKeyParams struct {
+	UserID          pgtype.UUID `db:"user_id" json:"user_id"`
+	Key             string      `db:"key" json:"key"`
+	StatusCode      pgtype.Int4 `db:"status_code" json:"status_code"`
+	ResponseBody    []byte      `db:"response_body" json:"response_body"`
+	ResponseHeaders []byte      `db:"response_headers" json:"response_headers"`
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, completeIdempotencyKey,
+		arg.UserID,
+		arg.Key,
+		arg.StatusCode,
+		arg.ResponseBody,
+		arg.ResponseHeaders,
+	)
+	return err
+}
+
+const countUnreadNotifications = `-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) CountUnreadNotifications(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnreadNotifications, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createDish = `-- name: CreateDish :one
 INSERT INTO dishes (
     id,
@@ -58,7 +267,7 @@ func (q *Queries) CreateDish(ctx context.Context, arg CreateDishParams) (Dish, e
 
 const createNotification = `-- name: CreateNotification :one
 INSERT INTO notifications (id, user_id, order_id, message)
-VALUES ($1, $2, $3, $4) RETURNING id, user_id, order_id, message, sent_at
+VALUES ($1, $2, $3, $4) RETURNING id, user_id, order_id, message, sent_at, read_at
 `
 
 type CreateNotificationParams struct {
@@ -82,64 +291,238 @@ func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotification
 		&i.OrderID,
 		&i.Message,
 		&i.SentAt,
+		&i.ReadAt,
 	)
 	return i, err
 }
 
 const createOrder = `-- name: CreateOrder :one
-INSERT INTO orders (id, user_id, dish_id, status)
-VALUES ($1, $2, $3, $4) RETURNING id, user_id, dish_id, status, created_at, updated_at
+INSERT INTO orders (id, user_id, status)
+VALUES ($1, $2, $3) RETURNING id, user_id, status, created_at, updated_at
 `
 
 type CreateOrderParams struct {
 	ID     pgtype.UUID `db:"id" json:"id"`
 	UserID pgtype.UUID `db:"user_id" json:"user_id"`
-	DishID pgtype.UUID `db:"dish_id" json:"dish_id"`
 	Status string      `db:"status" json:"status"`
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
-	row := q.db.QueryRow(ctx, createOrder,
+	row := q.db.QueryRow(ctx, createOrder, arg.ID, arg.UserID, arg.Status)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createOrderItem = `-- name: CreateOrderItem :one
+INSERT INTO order_items (id, order_id, dish_id, quantity, unit_price, notes)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, order_id, dish_id, quantity, unit_price, notes, created_at
+`
+
+type CreateOrderItemParams struct {
+	ID        pgtype.UUID    `db:"id" json:"id"`
+	OrderID   pgtype.UUID    `db:"order_id" json:"order_id"`
+	DishID    pgtype.UUID    `db:"dish_id" json:"dish_id"`
+	Quantity  int32          `db:"quantity" json:"quantity"`
+	UnitPrice pgtype.Numeric `db:"unit_price" json:"unit_price"`
+	Notes     pgtype.Text    `db:"notes" json:"notes"`
+}
+
+func (q *Queries) CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) (OrderItem, error) {
+	row := q.db.QueryRow(ctx, createOrderItem,
 		arg.ID,
-		arg.UserID,
+		arg.OrderID,
 		arg.DishID,
-		arg.Status,
+		arg.Quantity,
+		arg.UnitPrice,
+		arg.Notes,
 	)
-	var i Order
+	var i OrderItem
 	err := row.Scan(
 		&i.ID,
-		&i.UserID,
+		&i.OrderID,
 		&i.DishID,
+		&i.Quantity,
+		&i.UnitPrice,
+		&i.Notes,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox (id, event_type, status, payload, created_at)
+VALUES ($1, $2, $3, $4, $5) RETURNING id, event_type, status, payload, created_at, attempts, last_error, next_attempt_at, delivered_at
+`
+
+type CreateOutboxEventParams struct {
+	ID        pgtype.UUID      `db:"id" json:"id"`
+	EventType string           `db:"event_type" json:"event_type"`
+	Status    string           `db:"status" json:"status"`
+	Payload   []byte           `db:"payload" json:"payload"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error) {
+	row := q.db.QueryRow(ctx, createOutboxEvent,
+		arg.ID,
+		arg.EventType,
+		arg.Status,
+		arg.Payload,
+		arg.CreatedAt,
+	)
+	var i Outbox
+	err := row.Scan(
+		&i.ID,
+		&i.EventType,
 		&i.Status,
+		&i.Payload,
 		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.DeliveredAt,
 	)
 	return i, err
 }
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, name, email)
-VALUES ($1, $2, $3) RETURNING id, name, email, created_at
+INSERT INTO users (id, name, email, password_hash)
+VALUES ($1, $2, $3, $4) RETURNING id, name, email, created_at, password_hash
 `
 
 type CreateUserParams struct {
-	ID    pgtype.UUID `db:"id" json:"id"`
-	Name  string      `db:"name" json:"name"`
-	Email string      `db:"email" json:"email"`
+	ID           pgtype.UUID `db:"id" json:"id"`
+	Name         string      `db:"name" json:"name"`
+	Email        string      `db:"email" json:"email"`
+	PasswordHash pgtype.Text `db:"password_hash" json:"password_hash"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.ID, arg.Name, arg.Email)
+	row := q.db.QueryRow(ctx, createUser,
+		arg.ID,
+		arg.Name,
+		arg.Email,
+		arg.PasswordHash,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.Email,
 		&i.CreatedAt,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :execrows
+INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (subscription_id, event_id) DO NOTHING
+`
+
+type CreateWebhookDeliveryParams struct {
+	ID             pgtype.UUID `db:"id" json:"id"`
+	SubscriptionID pgtype.UUID `db:"subscription_id" json:"subscription_id"`
+	EventID        pgtype.UUID `db:"event_id" json:"event_id"`
+	EventType      string      `db:"event_type" json:"event_type"`
+	Payload        []byte      `db:"payload" json:"payload"`
+}
+
+// Un evento reentregado por el EventBus no genera una segunda entrega
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createWebhookDelivery,
+		arg.ID,
+		arg.SubscriptionID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createWebhookDeliveryAttempt = `-- name: CreateWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateWebhookDeliveryAttemptParams struct {
+	DeliveryID pgtype.UUID `db:"delivery_id" json:"delivery_id"`
+	StatusCode pgtype.Int4 `db:"status_code" json:"status_code"`
+	Error      pgtype.Text `db:"error" json:"error"`
+	DurationMs int32       `db:"duration_ms" json:"duration_ms"`
+}
+
+func (q *Queries) CreateWebhookDeliveryAttempt(ctx context.Context, arg CreateWebhookDeliveryAttemptParams) error {
+	_, err := q.db.Exec(ctx, createWebhookDeliveryAttempt,
+		arg.DeliveryID,
+		arg.StatusCode,
+		arg.Error,
+		arg.DurationMs,
+	)
+	return err
+}
+
+const createWebhookSubscription = `-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (id, url, event_types, secret)
+VALUES ($1, $2, $3, $4) RETURNING id, url, event_types, secret, active, created_at, updated_at
+`
+
+type CreateWebhookSubscriptionParams struct {
+	ID         pgtype.UUID `db:"id" json:"id"`
+	Url        string      `db:"url" json:"url"`
+	EventTypes []string    `db:"event_types" json:"event_types"`
+	Secret     string      `db:"secret" json:"secret"`
+}
+
+func (q *Queries) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, createWebhookSubscription,
+		arg.ID,
+		arg.Url,
+		arg.EventTypes,
+		arg.Secret,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.EventTypes,
+		&i.Secret,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const deleteAllMenuDishes = `-- name: DeleteAllMenuDishes :exec
+DELETE FROM menu_by_date
+`
+
+func (q *Queries) DeleteAllMenuDishes(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteAllMenuDishes)
+	return err
+}
+
+const deleteAllUserOrderHistory = `-- name: DeleteAllUserOrderHistory :exec
+DELETE FROM user_order_history
+`
+
+func (q *Queries) DeleteAllUserOrderHistory(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteAllUserOrderHistory)
+	return err
+}
+
 const deleteDish = `-- name: DeleteDish :exec
 DELETE FROM dishes
 WHERE id = $1
@@ -150,6 +533,44 @@ func (q *Queries) DeleteDish(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredIdempotencyKeys)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND key = $2
+`
+
+type DeleteIdempotencyKeyParams struct {
+	UserID pgtype.UUID `db:"user_id" json:"user_id"`
+	Key    string      `db:"key" json:"key"`
+}
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, deleteIdempotencyKey, arg.UserID, arg.Key)
+	return err
+}
+
+const deleteMenuDish = `-- name: DeleteMenuDish :exec
+DELETE FROM menu_by_date
+WHERE dish_id = $1
+`
+
+func (q *Queries) DeleteMenuDish(ctx context.Context, dishID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteMenuDish, dishID)
+	return err
+}
+
 const deleteUser = `-- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1
@@ -160,6 +581,40 @@ func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) error {
 	return err
 }
 
+const deleteUserOrderHistory = `-- name: DeleteUserOrderHistory :exec
+DELETE FROM user_order_history
+WHERE order_id = $1
+`
+
+func (q *Queries) DeleteUserOrderHistory(ctx context.Context, orderID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteUserOrderHistory, orderID)
+	return err
+}
+
+const deleteWebhookSubscription = `-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhookSubscription(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhookSubscription, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const ensureProjectionCheckpoint = `-- name: EnsureProjectionCheckpoint :exec
+INSERT INTO projection_checkpoints (name)
+VALUES ($1)
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) EnsureProjectionCheckpoint(ctx context.Context, name string) error {
+	_, err := q.db.Exec(ctx, ensureProjectionCheckpoint, name)
+	return err
+}
+
 const getDish = `-- name: GetDish :one
 SELECT id, name, description, price, prep_time_minutes, available_on, created_at, updated_at FROM dishes
 WHERE id = $1 LIMIT 1
@@ -237,26 +692,35 @@ func (q *Queries) GetDishesByDate(ctx context.Context, availableOn pgtype.Date)
 	return items, nil
 }
 
-const getNotificationsByUserId = `-- name: GetNotificationsByUserId :many
-SELECT id, user_id, order_id, message, sent_at FROM notifications
-WHERE user_id = $1
+const getDishesByDateRange = `-- name: GetDishesByDateRange :many
+SELECT id, name, description, price, prep_time_minutes, available_on, created_at, updated_at FROM dishes
+WHERE available_on BETWEEN $1 AND $2
+ORDER BY available_on, name
 `
 
-func (q *Queries) GetNotificationsByUserId(ctx context.Context, userID pgtype.UUID) ([]Notification, error) {
-	rows, err := q.db.Query(ctx, getNotificationsByUserId, userID)
+type GetDishesByDateRangeParams struct {
+	FromDate pgtype.Date `db:"from_date" json:"from_date"`
+	ToDate   pgtype.Date `db:"to_date" json:"to_date"`
+}
+
+func (q *Queries) GetDishesByDateRange(ctx context.Context, arg GetDishesByDateRangeParams) ([]Dish, error) {
+	rows, err := q.db.Query(ctx, getDishesByDateRange, arg.FromDate, arg.ToDate)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Notification
+	var items []Dish
 	for rows.Next() {
-		var i Notification
+		var i Dish
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
-			&i.OrderID,
-			&i.Message,
-			&i.SentAt,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.PrepTimeMinutes,
+			&i.AvailableOn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -268,32 +732,41 @@ func (q *Queries) GetNotificationsByUserId(ctx context.Context, userID pgtype.UU
 	return items, nil
 }
 
-const getOrder = `-- name: GetOrder :one
-SELECT id, user_id, dish_id, status, created_at, updated_at FROM orders
-WHERE id = $1 LIMIT 1
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT user_id, key, fingerprint, status_code, response_body, response_headers, created_at, expires_at FROM idempotency_keys
+WHERE user_id = $1 AND key = $2
 `
 
-func (q *Queries) GetOrder(ctx context.Context, id pgtype.UUID) (Order, error) {
-	row := q.db.QueryRow(ctx, getOrder, id)
-	var i Order
+type GetIdempotencyKeyParams struct {
+	UserID pgtype.UUID `db:"user_id" json:"user_id"`
+	Key    string      `db:"key" json:"key"`
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, arg.UserID, arg.Key)
+	var i IdempotencyKey
 	err := row.Scan(
-		&i.ID,
 		&i.UserID,
-		&i.DishID,
-		&i.Status,
+		&i.Key,
+		&i.Fingerprint,
+		&i.StatusCode,
+		&i.ResponseBody,
+		&i.ResponseHeaders,
 		&i.CreatedAt,
-		&i.UpdatedAt,
+		&i.ExpiresAt,
 	)
 	return i, err
 }
 
-const getOrdersByDishId = `-- name: GetOrdersByDishId :many
-SELECT id, user_id, dish_id, status, created_at, updated_at FROM orders
-WHERE dish_id = $1
+const getKitchenQueue = `-- name: GetKitchenQueue :many
+SELECT id, user_id, status, created_at, updated_at FROM orders
+WHERE status NOT IN ('served', 'cancelled')
+ORDER BY created_at, id
 `
 
-func (q *Queries) GetOrdersByDishId(ctx context.Context, dishID pgtype.UUID) ([]Order, error) {
-	rows, err := q.db.Query(ctx, getOrdersByDishId, dishID)
+// Órdenes activas, de la más antigua a la más reciente
+func (q *Queries) GetKitchenQueue(ctx context.Context) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getKitchenQueue)
 	if err != nil {
 		return nil, err
 	}
@@ -304,7 +777,6 @@ func (q *Queries) GetOrdersByDishId(ctx context.Context, dishID pgtype.UUID) ([]
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
-			&i.DishID,
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
@@ -319,24 +791,847 @@ func (q *Queries) GetOrdersByDishId(ctx context.Context, dishID pgtype.UUID) ([]
 	return items, nil
 }
 
-const getOrdersByStatus = `-- name: GetOrdersByStatus :many
-SELECT id, user_id, dish_id, status, created_at, updated_at FROM orders
-WHERE status = $1
+const getMenuByDate = `-- name: GetMenuByDate :many
+SELECT dish_id, available_on, name, description, price, prep_time_minutes, version FROM menu_by_date
+WHERE available_on = $1
+ORDER BY name
 `
 
-func (q *Queries) GetOrdersByStatus(ctx context.Context, status string) ([]Order, error) {
-	rows, err := q.db.Query(ctx, getOrdersByStatus, status)
+func (q *Queries) GetMenuByDate(ctx context.Context, availableOn pgtype.Date) ([]MenuByDate, error) {
+	rows, err := q.db.Query(ctx, getMenuByDate, availableOn)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Order
+	var items []MenuByDate
 	for rows.Next() {
-		var i Order
+		var i MenuByDate
+		if err := rows.Scan(
+			&i.DishID,
+			&i.AvailableOn,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.PrepTimeMinutes,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationChannels = `-- name: GetNotificationChannels :many
+SELECT channel FROM notification_deliveries
+WHERE notification_id = $1
+`
+
+// Canales por los que ya se entregó la notificación
+func (q *Queries) GetNotificationChannels(ctx context.Context, notificationID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, getNotificationChannels, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var channel string
+		if err := rows.Scan(&channel); err != nil {
+			return nil, err
+		}
+		items = append(items, channel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationsByUserId = `-- name: GetNotificationsByUserId :many
+SELECT id, user_id, order_id, message, sent_at, read_at FROM notifications
+WHERE user_id = $1
+`
+
+func (q *Queries) GetNotificationsByUserId(ctx context.Context, userID pgtype.UUID) ([]Notification, error) {
+	rows, err := q.db.Query(ctx, getNotificationsByUserId, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.OrderID,
+			&i.Message,
+			&i.SentAt,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrder = `-- name: GetOrder :one
+SELECT id, user_id, status, created_at, updated_at FROM orders
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetOrder(ctx context.Context, id pgtype.UUID) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrder, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getOrderForUpdate = `-- name: GetOrderForUpdate :one
+SELECT id, user_id, status, created_at, updated_at FROM orders
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetOrderForUpdate(ctx context.Context, id pgtype.UUID) (Order, error) {
+	row := q.db.QueryRow(ctx, getOrderForUpdate, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getOrderItemsByOrderIds = `-- name: GetOrderItemsByOrderIds :many
+SELECT
+    oi.id, oi.order_id, oi.dish_id, oi.quantity, oi.unit_price, oi.notes, oi.created_at,
+    d.name as dish_name,
+    d.description as dish_description,
+    d.prep_time_minutes as dish_prep_time_minutes
+FROM order_items oi
+JOIN dishes d ON oi.dish_id = d.id
+WHERE oi.order_id = ANY($1::uuid[])
+ORDER BY oi.created_at, oi.id
+`
+
+type GetOrderItemsByOrderIdsRow struct {
+	ID                  pgtype.UUID      `db:"id" json:"id"`
+	OrderID             pgtype.UUID      `db:"order_id" json:"order_id"`
+	DishID              pgtype.UUID      `db:"dish_id" json:"dish_id"`
+	Quantity            int32            `db:"quantity" json:"quantity"`
+	UnitPrice           pgtype.Numeric   `db:"unit_price" json:"unit_price"`
+	Notes               pgtype.Text      `db:"notes" json:"notes"`
+	CreatedAt           pgtype.Timestamp `db:"created_at" json:"created_at"`
+	DishName            string           `db:"dish_name" json:"dish_name"`
+	DishDescription     pgtype.Text      `db:"dish_description" json:"dish_description"`
+	DishPrepTimeMinutes int32            `db:"dish_prep_time_minutes" json:"dish_prep_time_minutes"`
+}
+
+func (q *Queries) GetOrderItemsByOrderIds(ctx context.Context, orderIds []pgtype.UUID) ([]GetOrderItemsByOrderIdsRow, error) {
+	rows, err := q.db.Query(ctx, getOrderItemsByOrderIds, orderIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOrderItemsByOrderIdsRow
+	for rows.Next() {
+		var i GetOrderItemsByOrderIdsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.DishID,
+			&i.Quantity,
+			&i.UnitPrice,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.DishName,
+			&i.DishDescription,
+			&i.DishPrepTimeMinutes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrdersByDishId = `-- name: GetOrdersByDishId :many
+SELECT DISTINCT o.id, o.user_id, o.status, o.created_at, o.updated_at FROM orders o
+JOIN order_items oi ON oi.order_id = o.id
+WHERE oi.dish_id = $1
+`
+
+func (q *Queries) GetOrdersByDishId(ctx context.Context, dishID pgtype.UUID) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getOrdersByDishId, dishID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrdersByStatus = `-- name: GetOrdersByStatus :many
+SELECT id, user_id, status, created_at, updated_at FROM orders
+WHERE status = $1
+`
+
+func (q *Queries) GetOrdersByStatus(ctx context.Context, status string) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getOrdersByStatus, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOrdersByUserId = `-- name: GetOrdersByUserId :many
+SELECT id, user_id, status, created_at, updated_at FROM orders
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetOrdersByUserId(ctx context.Context, userID pgtype.UUID) ([]Order, error) {
+	rows, err := q.db.Query(ctx, getOrdersByUserId, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingOutboxEvents = `-- name: GetPendingOutboxEvents :many
+SELECT id, event_type, status, payload, created_at, attempts, last_error, next_attempt_at, delivered_at FROM outbox
+WHERE delivered_at IS NULL AND next_attempt_at <= now()
+ORDER BY created_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) GetPendingOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, getPendingOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Status,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPermissions = `-- name: GetPermissions :many
+SELECT id, name FROM permissions
+`
+
+func (q *Queries) GetPermissions(ctx context.Context) ([]Permission, error) {
+	rows, err := q.db.Query(ctx, getPermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Permission
+	for rows.Next() {
+		var i Permission
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRolePermissions = `-- name: GetRolePermissions :many
+SELECT role_id, permission_id FROM role_permissions
+`
+
+func (q *Queries) GetRolePermissions(ctx context.Context) ([]RolePermission, error) {
+	rows, err := q.db.Query(ctx, getRolePermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RolePermission
+	for rows.Next() {
+		var i RolePermission
+		if err := rows.Scan(&i.RoleID, &i.PermissionID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRoles = `-- name: GetRoles :many
+SELECT id, name FROM roles
+`
+
+func (q *Queries) GetRoles(ctx context.Context) ([]Role, error) {
+	rows, err := q.db.Query(ctx, getRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Role
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, name, email, created_at, password_hash FROM users
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, name, email, created_at, password_hash FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Email,
+		&i.CreatedAt,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const getUserNotifications = `-- name: GetUserNotifications :many
+SELECT id, user_id, order_id, message, sent_at, read_at FROM notifications
+WHERE user_id = $1
+  AND (NOT $2::bool OR read_at IS NULL)
+  AND ($3::timestamp IS NULL
+       OR (sent_at, id) < ($3::timestamp, $4::uuid))
+ORDER BY sent_at DESC, id DESC
+LIMIT $5
+`
+
+type GetUserNotificationsParams struct {
+	UserID       pgtype.UUID      `db:"user_id" json:"user_id"`
+	UnreadOnly   bool             `db:"unread_only" json:"unread_only"`
+	CursorSentAt pgtype.Timestamp `db:"cursor_sent_at" json:"cursor_sent_at"`
+	CursorID     pgtype.UUID      `db:"cursor_id" json:"cursor_id"`
+	RowLimit     int32            `db:"row_limit" json:"row_limit"`
+}
+
+// Notificaciones de un usuario de la más reciente a la más antigua. El cursor
+// es (sent_at, id) de la última notificación de la página anterior; en NULL
+// se parte desde la más reciente.
+func (q *Queries) GetUserNotifications(ctx context.Context, arg GetUserNotificationsParams) ([]Notification, error) {
+	rows, err := q.db.Query(ctx, getUserNotifications,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.CursorSentAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.OrderID,
+			&i.Message,
+			&i.SentAt,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserOrderHistory = `-- name: GetUserOrderHistory :many
+SELECT order_id, user_id, status, total, items, created_at, updated_at, version FROM user_order_history
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetUserOrderHistory(ctx context.Context, userID pgtype.UUID) ([]UserOrderHistory, error) {
+	rows, err := q.db.Query(ctx, getUserOrderHistory, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserOrderHistory
+	for rows.Next() {
+		var i UserOrderHistory
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.UserID,
+			&i.Status,
+			&i.Total,
+			&i.Items,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserPermissions = `-- name: GetUserPermissions :many
+SELECT DISTINCT p.name
+FROM permissions p
+JOIN role_permissions rp ON rp.permission_id = p.id
+JOIN user_roles ur ON ur.role_id = rp.role_id
+WHERE ur.user_id = $1
+ORDER BY p.name
+`
+
+func (q *Queries) GetUserPermissions(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, getUserPermissions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserRoleNames = `-- name: GetUserRoleNames :many
+SELECT r.name
+FROM roles r
+JOIN user_roles ur ON ur.role_id = r.id
+WHERE ur.user_id = $1
+ORDER BY r.name
+`
+
+func (q *Queries) GetUserRoleNames(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, getUserRoleNames, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserRoles = `-- name: GetUserRoles :many
+SELECT user_id, role_id FROM user_roles
+`
+
+func (q *Queries) GetUserRoles(ctx context.Context) ([]UserRole, error) {
+	rows, err := q.db.Query(ctx, getUserRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UserRole
+	for rows.Next() {
+		var i UserRole
+		if err := rows.Scan(&i.UserID, &i.RoleID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, created_at, delivered_at FROM webhook_deliveries
+WHERE id = $1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id pgtype.UUID) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const getWebhookSubscription = `-- name: GetWebhookSubscription :one
+SELECT id, url, event_types, secret, active, created_at, updated_at FROM webhook_subscriptions
+WHERE id = $1
+`
+
+func (q *Queries) GetWebhookSubscription(ctx context.Context, id pgtype.UUID) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, getWebhookSubscription, id)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.EventTypes,
+		&i.Secret,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDishes = `-- name: ListDishes :many
+SELECT id, name, description, price, prep_time_minutes, available_on, created_at, updated_at FROM dishes
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListDishes(ctx context.Context) ([]Dish, error) {
+	rows, err := q.db.Query(ctx, listDishes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Dish
+	for rows.Next() {
+		var i Dish
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Price,
+			&i.PrepTimeMinutes,
+			&i.AvailableOn,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEvents = `-- name: ListEvents :many
+SELECT position, id, event_type, status, aggregate_type, aggregate_id, payload, occurred_at, recorded_at, transaction_id FROM events
+WHERE ($1::text[] IS NULL OR event_type LIKE ANY($1::text[]))
+  AND ($2::uuid IS NULL OR aggregate_id = $2)
+  AND ($3::timestamp IS NULL OR occurred_at >= $3)
+  AND ($4::timestamp IS NULL OR occurred_at < $4)
+  AND ($5::timestamp IS NULL
+       OR (occurred_at, position) > ($5::timestamp, $6::bigint))
+ORDER BY occurred_at, position
+LIMIT $7
+`
+
+type ListEventsParams struct {
+	TypePatterns     []string         `db:"type_patterns" json:"type_patterns"`
+	AggregateID      pgtype.UUID      `db:"aggregate_id" json:"aggregate_id"`
+	OccurredFrom     pgtype.Timestamp `db:"occurred_from" json:"occurred_from"`
+	OccurredTo       pgtype.Timestamp `db:"occurred_to" json:"occurred_to"`
+	CursorOccurredAt pgtype.Timestamp `db:"cursor_occurred_at" json:"cursor_occurred_at"`
+	CursorPosition   pgtype.Int8      `db:"cursor_position" json:"cursor_position"`
+	RowLimit         int32            `db:"row_limit" json:"row_limit"`
+}
+
+// Historial de eventos en orden cronológico. type_patterns son patrones LIKE
+// del tipo de evento; los filtros en NULL no se aplican. El cursor es
+// (occurred_at, position) del último evento de la página anterior.
+func (q *Queries) ListEvents(ctx context.Context, arg ListEventsParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEvents,
+		arg.TypePatterns,
+		arg.AggregateID,
+		arg.OccurredFrom,
+		arg.OccurredTo,
+		arg.CursorOccurredAt,
+		arg.CursorPosition,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.Position,
+			&i.ID,
+			&i.EventType,
+			&i.Status,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.Payload,
+			&i.OccurredAt,
+			&i.RecordedAt,
+			&i.TransactionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEventsAfter = `-- name: ListEventsAfter :many
+SELECT position, id, event_type, status, aggregate_type, aggregate_id, payload, occurred_at, recorded_at, transaction_id FROM events
+WHERE (transaction_id, position) > ($1::bigint, $2::bigint)
+  AND transaction_id < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+  AND event_type LIKE ANY($3::text[])
+ORDER BY transaction_id, position
+LIMIT $4
+`
+
+type ListEventsAfterParams struct {
+	AfterTransactionID int64    `db:"after_transaction_id" json:"after_transaction_id"`
+	AfterPosition      int64    `db:"after_position" json:"after_position"`
+	TypePatterns       []string `db:"type_patterns" json:"type_patterns"`
+	RowLimit           int32    `db:"row_limit" json:"row_limit"`
+}
+
+// Eventos del historial posteriores a un checkpoint (transaction_id,
+// position), en ese orden. Sólo se leen los eventos de transacciones
+// anteriores a la más antigua todavía activa: esas transacciones ya
+// terminaron, por lo que ningún evento puede aparecer después antes del
+// checkpoint. type_patterns son patrones LIKE del tipo de evento.
+func (q *Queries) ListEventsAfter(ctx context.Context, arg ListEventsAfterParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, listEventsAfter,
+		arg.AfterTransactionID,
+		arg.AfterPosition,
+		arg.TypePatterns,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.Position,
+			&i.ID,
+			&i.EventType,
+			&i.Status,
+			&i.AggregateType,
+			&i.AggregateID,
+			&i.Payload,
+			&i.OccurredAt,
+			&i.RecordedAt,
+			&i.TransactionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrders = `-- name: ListOrders :many
+SELECT o.id, o.user_id, o.status, o.created_at, o.updated_at FROM orders o
+WHERE ($1::text[] IS NULL OR o.status = ANY($1::text[]))
+  AND ($2::uuid IS NULL OR o.user_id = $2::uuid)
+  AND ($3::uuid IS NULL OR EXISTS (
+        SELECT 1 FROM order_items oi
+        WHERE oi.order_id = o.id AND oi.dish_id = $3::uuid
+      ))
+  AND ($4::timestamp IS NULL OR o.created_at >= $4::timestamp)
+  AND ($5::timestamp IS NULL OR o.created_at < $5::timestamp)
+ORDER BY o.created_at DESC, o.id
+LIMIT $7 OFFSET $6
+`
+
+type ListOrdersParams struct {
+	Statuses    []string         `db:"statuses" json:"statuses"`
+	UserID      pgtype.UUID      `db:"user_id" json:"user_id"`
+	DishID      pgtype.UUID      `db:"dish_id" json:"dish_id"`
+	CreatedFrom pgtype.Timestamp `db:"created_from" json:"created_from"`
+	CreatedTo   pgtype.Timestamp `db:"created_to" json:"created_to"`
+	RowOffset   int32            `db:"row_offset" json:"row_offset"`
+	RowLimit    int32            `db:"row_limit" json:"row_limit"`
+}
+
+// Los filtros en NULL no se aplican. El rango de fechas es [created_from, created_to).
+func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrders,
+		arg.Statuses,
+		arg.UserID,
+		arg.DishID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.RowOffset,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
-			&i.DishID,
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
@@ -351,48 +1646,55 @@ func (q *Queries) GetOrdersByStatus(ctx context.Context, status string) ([]Order
 	return items, nil
 }
 
-const getOrdersByUserId = `-- name: GetOrdersByUserId :many
-SELECT
-    o.id, o.user_id, o.dish_id, o.status, o.created_at, o.updated_at,
-    d.name as dish_name,
-    d.description as dish_description,
-    d.price as dish_price
-FROM orders o
-JOIN dishes d ON o.dish_id = d.id
-WHERE o.user_id = $1
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, created_at, delivered_at FROM webhook_deliveries
+WHERE ($1::uuid IS NULL OR subscription_id = $1)
+  AND ($2::text IS NULL OR status = $2)
+  AND ($3::timestamp IS NULL
+       OR (created_at, id) < ($3::timestamp, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
 `
 
-type GetOrdersByUserIdRow struct {
-	ID              pgtype.UUID      `db:"id" json:"id"`
-	UserID          pgtype.UUID      `db:"user_id" json:"user_id"`
-	DishID          pgtype.UUID      `db:"dish_id" json:"dish_id"`
-	Status          string           `db:"status" json:"status"`
-	CreatedAt       pgtype.Timestamp `db:"created_at" json:"created_at"`
-	UpdatedAt       pgtype.Timestamp `db:"updated_at" json:"updated_at"`
-	DishName        string           `db:"dish_name" json:"dish_name"`
-	DishDescription pgtype.Text      `db:"dish_description" json:"dish_description"`
-	DishPrice       pgtype.Numeric   `db:"dish_price" json:"dish_price"`
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID  pgtype.UUID      `db:"subscription_id" json:"subscription_id"`
+	Status          pgtype.Text      `db:"status" json:"status"`
+	CursorCreatedAt pgtype.Timestamp `db:"cursor_created_at" json:"cursor_created_at"`
+	CursorID        pgtype.UUID      `db:"cursor_id" json:"cursor_id"`
+	RowLimit        int32            `db:"row_limit" json:"row_limit"`
 }
 
-func (q *Queries) GetOrdersByUserId(ctx context.Context, userID pgtype.UUID) ([]GetOrdersByUserIdRow, error) {
-	rows, err := q.db.Query(ctx, getOrdersByUserId, userID)
+// Entregas de la más reciente a la más antigua, opcionalmente de una
+// suscripción y con un estado. El cursor es (created_at, id) de la última
+// entrega de la página anterior.
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries,
+		arg.SubscriptionID,
+		arg.Status,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetOrdersByUserIdRow
+	var items []WebhookDelivery
 	for rows.Next() {
-		var i GetOrdersByUserIdRow
+		var i WebhookDelivery
 		if err := rows.Scan(
 			&i.ID,
-			&i.UserID,
-			&i.DishID,
+			&i.SubscriptionID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
 			&i.Status,
+			&i.Attempts,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.NextAttemptAt,
 			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.DishName,
-			&i.DishDescription,
-			&i.DishPrice,
+			&i.DeliveredAt,
 		); err != nil {
 			return nil, err
 		}
@@ -404,20 +1706,29 @@ func (q *Queries) GetOrdersByUserId(ctx context.Context, userID pgtype.UUID) ([]
 	return items, nil
 }
 
-const getPermissions = `-- name: GetPermissions :many
-SELECT id, name FROM permissions
+const listWebhookDeliveryAttempts = `-- name: ListWebhookDeliveryAttempts :many
+SELECT id, delivery_id, status_code, error, duration_ms, attempted_at FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempted_at, id
 `
 
-func (q *Queries) GetPermissions(ctx context.Context) ([]Permission, error) {
-	rows, err := q.db.Query(ctx, getPermissions)
+func (q *Queries) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID pgtype.UUID) ([]WebhookDeliveryAttempt, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveryAttempts, deliveryID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Permission
+	var items []WebhookDeliveryAttempt
 	for rows.Next() {
-		var i Permission
-		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+		var i WebhookDeliveryAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.StatusCode,
+			&i.Error,
+			&i.DurationMs,
+			&i.AttemptedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -428,20 +1739,29 @@ func (q *Queries) GetPermissions(ctx context.Context) ([]Permission, error) {
 	return items, nil
 }
 
-const getRolePermissions = `-- name: GetRolePermissions :many
-SELECT role_id, permission_id FROM role_permissions
+const listWebhookSubscriptions = `-- name: ListWebhookSubscriptions :many
+SELECT id, url, event_types, secret, active, created_at, updated_at FROM webhook_subscriptions
+ORDER BY created_at, id
 `
 
-func (q *Queries) GetRolePermissions(ctx context.Context) ([]RolePermission, error) {
-	rows, err := q.db.Query(ctx, getRolePermissions)
+func (q *Queries) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptions)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []RolePermission
+	var items []WebhookSubscription
 	for rows.Next() {
-		var i RolePermission
-		if err := rows.Scan(&i.RoleID, &i.PermissionID); err != nil {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.EventTypes,
+			&i.Secret,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -452,20 +1772,30 @@ func (q *Queries) GetRolePermissions(ctx context.Context) ([]RolePermission, err
 	return items, nil
 }
 
-const getRoles = `-- name: GetRoles :many
-SELECT id, name FROM roles
+const listWebhookSubscriptionsForEvent = `-- name: ListWebhookSubscriptionsForEvent :many
+SELECT id, url, event_types, secret, active, created_at, updated_at FROM webhook_subscriptions
+WHERE active AND $1::text = ANY(event_types)
 `
 
-func (q *Queries) GetRoles(ctx context.Context) ([]Role, error) {
-	rows, err := q.db.Query(ctx, getRoles)
+// Suscripciones activas que escuchan el tipo de evento
+func (q *Queries) ListWebhookSubscriptionsForEvent(ctx context.Context, eventType string) ([]WebhookSubscription, error) {
+	rows, err := q.db.Query(ctx, listWebhookSubscriptionsForEvent, eventType)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Role
+	var items []WebhookSubscription
 	for rows.Next() {
-		var i Role
-		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+		var i WebhookSubscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.EventTypes,
+			&i.Secret,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -476,57 +1806,46 @@ func (q *Queries) GetRoles(ctx context.Context) ([]Role, error) {
 	return items, nil
 }
 
-const getUser = `-- name: GetUser :one
-SELECT id, name, email, created_at FROM users
-WHERE id = $1 LIMIT 1
+const lockProjectionCheckpoint = `-- name: LockProjectionCheckpoint :one
+SELECT transaction_id, position FROM projection_checkpoints
+WHERE name = $1
+FOR UPDATE
 `
 
-func (q *Queries) GetUser(ctx context.Context, id pgtype.UUID) (User, error) {
-	row := q.db.QueryRow(ctx, getUser, id)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.CreatedAt,
-	)
-	return i, err
+type LockProjectionCheckpointRow struct {
+	TransactionID int64 `db:"transaction_id" json:"transaction_id"`
+	Position      int64 `db:"position" json:"position"`
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, created_at FROM users
-WHERE email = $1 LIMIT 1
-`
-
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByEmail, email)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Email,
-		&i.CreatedAt,
-	)
+// Bloquea el checkpoint hasta el fin de la transacción, de modo que dos
+// instancias no apliquen a la vez los mismos eventos
+func (q *Queries) LockProjectionCheckpoint(ctx context.Context, name string) (LockProjectionCheckpointRow, error) {
+	row := q.db.QueryRow(ctx, lockProjectionCheckpoint, name)
+	var i LockProjectionCheckpointRow
+	err := row.Scan(&i.TransactionID, &i.Position)
 	return i, err
 }
 
-const getUserRoles = `-- name: GetUserRoles :many
-SELECT user_id, role_id FROM user_roles
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :many
+UPDATE notifications
+SET read_at = now()
+WHERE user_id = $1 AND read_at IS NULL
+RETURNING id
 `
 
-func (q *Queries) GetUserRoles(ctx context.Context) ([]UserRole, error) {
-	rows, err := q.db.Query(ctx, getUserRoles)
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, markAllNotificationsRead, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []UserRole
+	var items []pgtype.UUID
 	for rows.Next() {
-		var i UserRole
-		if err := rows.Scan(&i.UserID, &i.RoleID); err != nil {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, id)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -534,38 +1853,299 @@ func (q *Queries) GetUserRoles(ctx context.Context) ([]UserRole, error) {
 	return items, nil
 }
 
-const listDishes = `-- name: ListDishes :many
-SELECT id, name, description, price, prep_time_minutes, available_on, created_at, updated_at FROM dishes
-ORDER BY created_at DESC
+const markNotificationRead = `-- name: MarkNotificationRead :one
+UPDATE notifications
+SET read_at = COALESCE(read_at, now())
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, order_id, message, sent_at, read_at
 `
 
-func (q *Queries) ListDishes(ctx context.Context) ([]Dish, error) {
-	rows, err := q.db.Query(ctx, listDishes)
+type MarkNotificationReadParams struct {
+	ID     pgtype.UUID `db:"id" json:"id"`
+	UserID pgtype.UUID `db:"user_id" json:"user_id"`
+}
+
+// Una notificación ya leída conserva su read_at
+func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) (Notification, error) {
+	row := q.db.QueryRow(ctx, markNotificationRead, arg.ID, arg.UserID)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OrderID,
+		&i.Message,
+		&i.SentAt,
+		&i.ReadAt,
+	)
+	return i, err
+}
+
+const markOutboxEventDelivered = `-- name: MarkOutboxEventDelivered :exec
+UPDATE outbox
+SET delivered_at = now(),
+    attempts = attempts + 1,
+    last_error = NULL
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxEventDelivered(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markOutboxEventDelivered, id)
+	return err
+}
+
+const markOutboxEventFailed = `-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = $3
+WHERE id = $1
+`
+
+type MarkOutboxEventFailedParams struct {
+	ID            pgtype.UUID      `db:"id" json:"id"`
+	LastError     pgtype.Text      `db:"last_error" json:"last_error"`
+	NextAttemptAt pgtype.Timestamp `db:"next_attempt_at" json:"next_attempt_at"`
+}
+
+func (q *Queries) MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventFailed, arg.ID, arg.LastError, arg.NextAttemptAt)
+	return err
+}
+
+const markWebhookDeliveryDelivered = `-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered',
+    attempts = attempts + 1,
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = now()
+WHERE id = $1
+`
+
+type MarkWebhookDeliveryDeliveredParams struct {
+	ID             pgtype.UUID `db:"id" json:"id"`
+	LastStatusCode pgtype.Int4 `db:"last_status_code" json:"last_status_code"`
+}
+
+func (q *Queries) MarkWebhookDeliveryDelivered(ctx context.Context, arg MarkWebhookDeliveryDeliveredParams) error {
+	_, err := q.db.Exec(ctx, markWebhookDeliveryDelivered, arg.ID, arg.LastStatusCode)
+	return err
+}
+
+const markWebhookDeliveryFailed = `-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    last_status_code = $3,
+    last_error = $4,
+    next_attempt_at = $5
+WHERE id = $1
+`
+
+type MarkWebhookDeliveryFailedParams struct {
+	ID             pgtype.UUID      `db:"id" json:"id"`
+	Status         string           `db:"status" json:"status"`
+	LastStatusCode pgtype.Int4      `db:"last_status_code" json:"last_status_code"`
+	LastError      pgtype.Text      `db:"last_error" json:"last_error"`
+	NextAttemptAt  pgtype.Timestamp `db:"next_attempt_at" json:"next_attempt_at"`
+}
+
+// status queda en 'pending' para reintentar en next_attempt_at o pasa a 'dead'
+func (q *Queries) MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error {
+	_, err := q.db.Exec(ctx, markWebhookDeliveryFailed,
+		arg.ID,
+		arg.Status,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.NextAttemptAt,
+	)
+	return err
+}
+
+const recordNotificationDelivery = `-- name: RecordNotificationDelivery :exec
+INSERT INTO notification_deliveries (notification_id, channel)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type RecordNotificationDeliveryParams struct {
+	NotificationID pgtype.UUID `db:"notification_id" json:"notification_id"`
+	Channel        string      `db:"channel" json:"channel"`
+}
+
+func (q *Queries) RecordNotificationDelivery(ctx context.Context, arg RecordNotificationDeliveryParams) error {
+	_, err := q.db.Exec(ctx, recordNotificationDelivery, arg.NotificationID, arg.Channel)
+	return err
+}
+
+const redeliverWebhookDelivery = `-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = now(),
+    delivered_at = NULL
+WHERE id = $1
+RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, last_status_code, last_error, next_attempt_at, created_at, delivered_at
+`
+
+// Vuelve a encolar una entrega con todos sus intentos disponibles
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, id pgtype.UUID) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, redeliverWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.NextAttemptAt,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const reserveIdempotencyKey = `-- name: ReserveIdempotencyKey :execrows
+INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    now() + make_interval(secs => $4::float8)
+)
+ON CONFLICT (user_id, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    status_code = NULL,
+    response_body = NULL,
+    response_headers = NULL,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < now()
+   OR (idempotency_keys.status_code IS NULL
+       AND idempotency_keys.created_at < now() - make_interval(secs => $5::float8))
+`
+
+type ReserveIdempotencyKeyParams struct {
+	UserID       pgtype.UUID `db:"user_id" json:"user_id"`
+	Key          string      `db:"key" json:"key"`
+	Fingerprint  string      `db:"fingerprint" json:"fingerprint"`
+	TtlSeconds   float64     `db:"ttl_seconds" json:"ttl_seconds"`
+	LeaseSeconds float64     `db:"lease_seconds" json:"lease_seconds"`
+}
+
+// Reserva la llave para un request nuevo. Si la llave existe y no ha expirado
+// no modifica nada y retorna 0 filas; una llave expirada se reutiliza. Una
+// reserva sin respuesta (status_code NULL) de hace más de lease_seconds se
+// considera abandonada, por ejemplo porque el writer se cayó, y también se
+// reutiliza.
+func (q *Queries) ReserveIdempotencyKey(ctx context.Context, arg ReserveIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reserveIdempotencyKey,
+		arg.UserID,
+		arg.Key,
+		arg.Fingerprint,
+		arg.TtlSeconds,
+		arg.LeaseSeconds,
+	)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Dish
-	for rows.Next() {
-		var i Dish
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Description,
-			&i.Price,
-			&i.PrepTimeMinutes,
-			&i.AvailableOn,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected(), nil
+}
+
+const saveProjectionCheckpoint = `-- name: SaveProjectionCheckpoint :exec
+UPDATE projection_checkpoints
+SET transaction_id = $2,
+    position = $3,
+    updated_at = now()
+WHERE name = $1
+`
+
+type SaveProjectionCheckpointParams struct {
+	Name          string `db:"name" json:"name"`
+	TransactionID int64  `db:"transaction_id" json:"transaction_id"`
+	Position      int64  `db:"position" json:"position"`
+}
+
+func (q *Queries) SaveProjectionCheckpoint(ctx context.Context, arg SaveProjectionCheckpointParams) error {
+	_, err := q.db.Exec(ctx, saveProjectionCheckpoint, arg.Name, arg.TransactionID, arg.Position)
+	return err
+}
+
+const seedMenuByDate = `-- name: SeedMenuByDate :exec
+INSERT INTO menu_by_date (dish_id, available_on, name, description, price, prep_time_minutes, version)
+SELECT id, available_on, name, COALESCE(description, ''), price, prep_time_minutes, COALESCE(updated_at, now())
+FROM dishes
+ON CONFLICT DO NOTHING
+`
+
+// Carga menu_by_date desde la tabla dishes, igual que la migración que la
+// creó. version es updated_at, por lo que los eventos anteriores al estado
+// actual del plato no lo sobrescriben.
+func (q *Queries) SeedMenuByDate(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, seedMenuByDate)
+	return err
+}
+
+const seedUserOrderHistory = `-- name: SeedUserOrderHistory :exec
+INSERT INTO user_order_history (order_id, user_id, status, total, items, created_at, updated_at, version)
+SELECT
+    o.id,
+    o.user_id,
+    o.status,
+    COALESCE(SUM(oi.unit_price * oi.quantity), 0),
+    COALESCE(
+        jsonb_agg(jsonb_build_object(
+            'id', oi.id,
+            'dish_id', oi.dish_id,
+            'dish_name', d.name,
+            'dish_description', COALESCE(d.description, ''),
+            'quantity', oi.quantity,
+            'unit_price', oi.unit_price::text,
+            'subtotal', (oi.unit_price * oi.quantity)::text,
+            'notes', COALESCE(oi.notes, ''),
+            'prep_time_minutes', d.prep_time_minutes
+        ) ORDER BY oi.created_at, oi.id) FILTER (WHERE oi.id IS NOT NULL),
+        '[]'::jsonb
+    ),
+    COALESCE(o.created_at, now()),
+    COALESCE(o.updated_at, now()),
+    COALESCE(o.updated_at, now())
+FROM orders o
+LEFT JOIN order_items oi ON oi.order_id = o.id
+LEFT JOIN dishes d ON d.id = oi.dish_id
+GROUP BY o.id
+ON CONFLICT DO NOTHING
+`
+
+// Carga user_order_history desde orders y order_items, igual que la migración
+// que la creó. version es updated_at, por lo que los eventos anteriores al
+// estado actual de la orden no la sobrescriben.
+func (q *Queries) SeedUserOrderHistory(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, seedUserOrderHistory)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :execrows
+UPDATE users
+SET password_hash = $2
+WHERE id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID           pgtype.UUID `db:"id" json:"id"`
+	PasswordHash pgtype.Text `db:"password_hash" json:"password_hash"`
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserPassword, arg.ID, arg.PasswordHash)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
 const updateDish = `-- name: UpdateDish :one
@@ -618,7 +2198,7 @@ UPDATE orders
 SET status = $2,
     updated_at = now()
 WHERE id = $1
-RETURNING id, user_id, dish_id, status, created_at, updated_at
+RETURNING id, user_id, status, created_at, updated_at
 `
 
 type UpdateOrderStatusParams struct {
@@ -632,7 +2212,6 @@ func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusPa
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.DishID,
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
@@ -644,7 +2223,7 @@ const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET name = $2, email = $3
 WHERE id = $1
-RETURNING id, name, email, created_at
+RETURNING id, name, email, created_at, password_hash
 `
 
 type UpdateUserParams struct {
@@ -661,6 +2240,118 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Name,
 		&i.Email,
 		&i.CreatedAt,
+		&i.PasswordHash,
+	)
+	return i, err
+}
+
+const updateWebhookSubscription = `-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET url = $2,
+    event_types = $3,
+    active = $4,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, url, event_types, secret, active, created_at, updated_at
+`
+
+type UpdateWebhookSubscriptionParams struct {
+	ID         pgtype.UUID `db:"id" json:"id"`
+	Url        string      `db:"url" json:"url"`
+	EventTypes []string    `db:"event_types" json:"event_types"`
+	Active     bool        `db:"active" json:"active"`
+}
+
+func (q *Queries) UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (WebhookSubscription, error) {
+	row := q.db.QueryRow(ctx, updateWebhookSubscription,
+		arg.ID,
+		arg.Url,
+		arg.EventTypes,
+		arg.Active,
+	)
+	var i WebhookSubscription
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.EventTypes,
+		&i.Secret,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
+
+const upsertMenuDish = `-- name: UpsertMenuDish :exec
+INSERT INTO menu_by_date (dish_id, available_on, name, description, price, prep_time_minutes, version)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (dish_id) DO UPDATE
+SET available_on = EXCLUDED.available_on,
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    price = EXCLUDED.price,
+    prep_time_minutes = EXCLUDED.prep_time_minutes,
+    version = EXCLUDED.version
+WHERE menu_by_date.version <= EXCLUDED.version
+`
+
+type UpsertMenuDishParams struct {
+	DishID          pgtype.UUID      `db:"dish_id" json:"dish_id"`
+	AvailableOn     pgtype.Date      `db:"available_on" json:"available_on"`
+	Name            string           `db:"name" json:"name"`
+	Description     string           `db:"description" json:"description"`
+	Price           pgtype.Numeric   `db:"price" json:"price"`
+	PrepTimeMinutes int32            `db:"prep_time_minutes" json:"prep_time_minutes"`
+	Version         pgtype.Timestamp `db:"version" json:"version"`
+}
+
+func (q *Queries) UpsertMenuDish(ctx context.Context, arg UpsertMenuDishParams) error {
+	_, err := q.db.Exec(ctx, upsertMenuDish,
+		arg.DishID,
+		arg.AvailableOn,
+		arg.Name,
+		arg.Description,
+		arg.Price,
+		arg.PrepTimeMinutes,
+		arg.Version,
+	)
+	return err
+}
+
+const upsertUserOrderHistory = `-- name: UpsertUserOrderHistory :exec
+INSERT INTO user_order_history (order_id, user_id, status, total, items, created_at, updated_at, version)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (order_id) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+    status = EXCLUDED.status,
+    total = EXCLUDED.total,
+    items = EXCLUDED.items,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_order_history.version <= EXCLUDED.version
+`
+
+type UpsertUserOrderHistoryParams struct {
+	OrderID   pgtype.UUID      `db:"order_id" json:"order_id"`
+	UserID    pgtype.UUID      `db:"user_id" json:"user_id"`
+	Status    string           `db:"status" json:"status"`
+	Total     pgtype.Numeric   `db:"total" json:"total"`
+	Items     []byte           `db:"items" json:"items"`
+	CreatedAt pgtype.Timestamp `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamp `db:"updated_at" json:"updated_at"`
+	Version   pgtype.Timestamp `db:"version" json:"version"`
+}
+
+func (q *Queries) UpsertUserOrderHistory(ctx context.Context, arg UpsertUserOrderHistoryParams) error {
+	_, err := q.db.Exec(ctx, upsertUserOrderHistory,
+		arg.OrderID,
+		arg.UserID,
+		arg.Status,
+		arg.Total,
+		arg.Items,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Version,
+	)
+	return err
+}
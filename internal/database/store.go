@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store expone las consultas generadas por sqlc y permite ejecutar varias de
+// ellas dentro de una misma transacción
+type Store interface {
+	Querier
+	// ExecTx ejecuta fn dentro de una transacción. Si fn retorna un error la
+	// transacción se revierte; en caso contrario se confirma.
+	ExecTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// SQLStore implementa Store sobre un pool de pgx
+type SQLStore struct {
+	*Queries
+	pool *pgxpool.Pool
+}
+
+// NewStore crea un nuevo Store a partir de un pool de conexiones
+func NewStore(pool *pgxpool.Pool) *SQLStore {
+	return &SQLStore{
+		Queries: New(pool),
+		pool:    pool,
+	}
+}
+
+// ExecTx implementa la interfaz Store
+func (s *SQLStore) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.Queries.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("%w (error al revertir la transacción: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+var _ Store = (*SQLStore)(nil)
@@ -0,0 +1,52 @@
+// Package eventstore republica rangos del historial de eventos (la tabla
+// events), por ejemplo para reconstruir un consumidor. Los eventos se agregan
+// al historial con cqrs.EnqueueEvent, en la misma transacción que el outbox.
+package eventstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/cqrs/queries"
+	"github.com/rodrwan/themenu/internal/database"
+)
+
+// Replay republica en orden cronológico los eventos del historial que cumplen
+// los filtros de query; Cursor y Limit se usan para recorrer el historial por
+// páginas. Los eventos conservan su ID y su instante originales, por lo que
+// los consumidores idempotentes los ignoran si ya los procesaron. Retorna la
+// cantidad de eventos republicados.
+func Replay(ctx context.Context, db database.Querier, publisher cqrs.EventDeliverer, query queries.ListEventsQuery) (int, error) {
+	query.Queries = db
+	if query.Limit == 0 {
+		query.Limit = queries.MaxListEventsLimit
+	}
+
+	replayed := 0
+	for {
+		page, err := query.Execute(ctx)
+		if err != nil {
+			return replayed, err
+		}
+
+		for _, stored := range page.Events {
+			event := cqrs.Event{
+				ID:        stored.ID,
+				Type:      stored.Type,
+				Status:    stored.Status,
+				Payload:   string(stored.Payload),
+				Timestamp: stored.Timestamp,
+			}
+			if err := publisher.Deliver(ctx, event); err != nil {
+				return replayed, fmt.Errorf("error al republicar el evento %s: %w", event.ID, err)
+			}
+			replayed++
+		}
+
+		if page.NextCursor == "" {
+			return replayed, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,373 @@
+// Package migrations versiona el esquema de la base de datos. Cada migración
+// es un par de archivos sql/NNNN_nombre.up.sql y sql/NNNN_nombre.down.sql que
+// se incluyen en el binario; las aplicadas se registran en schema_migrations
+// junto con el checksum de su script up.
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockID identifica el advisory lock que serializa las migraciones entre
+// procesos
+const lockID int64 = 7_262_019
+
+var (
+	ErrSchemaBehind     = errors.New("el esquema de la base de datos tiene migraciones pendientes")
+	ErrChecksumMismatch = errors.New("una migración aplicada fue modificada")
+	ErrNoDownMigration  = errors.New("la migración no tiene script down")
+)
+
+// Migration es una versión del esquema
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status es el estado de una migración en la base de datos
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Modified indica que el script up cambió después de aplicarse
+	Modified bool
+}
+
+// Migrator aplica y revierte migraciones
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New crea un Migrator con las migraciones incluidas en el binario
+func New(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// load lee las migraciones de fsys ordenadas por versión
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("nombre de migración inválido: %s", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("versión de migración inválida: %s", name)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join("sql", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("la versión %04d tiene dos nombres: %s y %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("la migración %04d_%s no tiene script up", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up aplica todas las migraciones pendientes y retorna las aplicadas
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		statuses, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkModified(statuses); err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			if s.Applied {
+				continue
+			}
+			if err := apply(ctx, conn, s.Migration); err != nil {
+				return err
+			}
+			applied = append(applied, s.Migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down revierte las últimas n migraciones aplicadas y retorna las revertidas
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		var err error
+		reverted, err = m.down(ctx, conn, n)
+		return err
+	})
+	return reverted, err
+}
+
+// Redo revierte la última migración aplicada y la vuelve a aplicar
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	var redone Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		reverted, err := m.down(ctx, conn, 1)
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			return errors.New("no hay migraciones aplicadas")
+		}
+		redone = reverted[0]
+		return apply(ctx, conn, redone)
+	})
+	return redone, err
+}
+
+// Status retorna el estado de cada migración, ordenadas por versión
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	return m.status(ctx, conn)
+}
+
+// Check retorna ErrSchemaBehind si hay migraciones pendientes y
+// ErrChecksumMismatch si alguna aplicada fue modificada. No crea ni modifica
+// tablas, por lo que puede usarse al iniciar un servicio.
+func (m *Migrator) Check(ctx context.Context) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: no se ha aplicado ninguna", ErrSchemaBehind)
+	}
+
+	statuses, err := m.status(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := checkModified(statuses); err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrSchemaBehind, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+func (m *Migrator) down(ctx context.Context, conn *pgxpool.Conn, n int) ([]Migration, error) {
+	statuses, err := m.status(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < n; i-- {
+		s := statuses[i]
+		if !s.Applied {
+			continue
+		}
+		if s.Down == "" {
+			return reverted, fmt.Errorf("%w: %04d_%s", ErrNoDownMigration, s.Version, s.Name)
+		}
+		if err := revert(ctx, conn, s.Migration); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, s.Migration)
+	}
+	return reverted, nil
+}
+
+// status cruza las migraciones conocidas con las registradas en
+// schema_migrations
+func (m *Migrator) status(ctx context.Context, conn *pgxpool.Conn) ([]Status, error) {
+	rows, err := conn.Query(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	type record struct {
+		checksum  string
+		appliedAt time.Time
+	}
+	applied := make(map[int64]record)
+	for rows.Next() {
+		var version int64
+		var r record
+		if err := rows.Scan(&version, &r.checksum, &r.appliedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		applied[version] = r
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Migration: migration}
+		if r, ok := applied[migration.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = r.appliedAt
+			statuses[i].Modified = r.checksum != migration.Checksum
+		}
+	}
+	return statuses, nil
+}
+
+// withLock ejecuta fn con una conexión que tiene el advisory lock de las
+// migraciones, de modo que dos procesos no migren al mismo tiempo
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("error al tomar el lock de migraciones: %w", err)
+	}
+	defer func() {
+		// El lock es de la sesión: se libera aunque el contexto se haya cancelado
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT now()
+)`)
+	return err
+}
+
+// apply ejecuta el script up y lo registra en la misma transacción
+func apply(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Up); err != nil {
+			return fmt.Errorf("error al aplicar %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.Exec(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+			migration.Version, migration.Name, migration.Checksum)
+		return err
+	})
+}
+
+// revert ejecuta el script down y elimina el registro en la misma transacción
+func revert(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Down); err != nil {
+			return fmt.Errorf("error al revertir %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		return err
+	})
+}
+
+func checkModified(statuses []Status) error {
+	var modified []string
+	for _, s := range statuses {
+		if s.Modified {
+			modified = append(modified, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(modified) > 0 {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, strings.Join(modified, ", "))
+	}
+	return nil
+}
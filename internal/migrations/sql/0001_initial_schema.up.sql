@@ -0,0 +1,113 @@
+-- Esquema inicial. Usa IF NOT EXISTS para adoptar las bases de datos creadas
+-- con el antiguo schema.sql, que ya tienen estas tablas.
+
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS dishes (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    price NUMERIC(10, 2) NOT NULL,
+    prep_time_minutes INT NOT NULL, -- tiempo estimado de preparación
+    available_on DATE NOT NULL, -- fecha en la que estará disponible
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    status TEXT NOT NULL CHECK (status IN ('received', 'confirmed', 'preparing', 'served', 'cancelled')),
+    created_at TIMESTAMP DEFAULT now(),
+    updated_at TIMESTAMP DEFAULT now()
+);
+
+-- Índice único parcial para asegurar que un usuario no tenga más de una orden activa
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_active_user ON orders (user_id)
+WHERE status NOT IN ('served', 'cancelled');
+
+-- Índice para los listados por estado y la cola de cocina
+CREATE INDEX IF NOT EXISTS idx_orders_status_created_at ON orders (status, created_at);
+
+-- Líneas de una orden: cada una referencia un plato con su cantidad y el precio
+-- unitario capturado al momento de ordenar
+CREATE TABLE IF NOT EXISTS order_items (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    dish_id UUID NOT NULL REFERENCES dishes(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(10, 2) NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items (order_id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    order_id UUID NOT NULL REFERENCES orders(id),
+    message TEXT NOT NULL,
+    sent_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id UUID PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL CHECK (name IN ('client', 'kitchen', 'admin'))
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id UUID PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id UUID NOT NULL REFERENCES roles(id),
+    permission_id UUID NOT NULL REFERENCES permissions(id),
+    PRIMARY KEY (role_id, permission_id)
+);
+
+-- Outbox transaccional: los eventos se escriben en la misma transacción que el
+-- cambio de dominio y un relay del writer los publica en el EventBus
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+    delivered_at TIMESTAMP
+);
+
+-- Índice parcial para que el relay encuentre rápido los eventos pendientes
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (next_attempt_at)
+WHERE delivered_at IS NULL;
+
+-- Respuestas guardadas de los requests con Idempotency-Key. Mientras el
+-- request original está en curso status_code es NULL.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    status_code INT,
+    response_body BYTEA,
+    response_headers JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_notifications_user_sent_at;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS read_at;
+
+ALTER TABLE notifications ALTER COLUMN sent_at DROP NOT NULL;
@@ -0,0 +1,8 @@
+-- Seguimiento de lectura de las notificaciones y paginación por usuario.
+-- sent_at pasa a ser obligatorio porque es parte del cursor de paginación.
+UPDATE notifications SET sent_at = now() WHERE sent_at IS NULL;
+ALTER TABLE notifications ALTER COLUMN sent_at SET NOT NULL;
+
+ALTER TABLE notifications ADD COLUMN read_at TIMESTAMP;
+
+CREATE INDEX idx_notifications_user_sent_at ON notifications (user_id, sent_at DESC, id DESC);
@@ -0,0 +1,34 @@
+-- Historial de todos los eventos publicados en el EventBus. La tabla es de
+-- sólo inserción: un trigger rechaza cualquier UPDATE o DELETE.
+CREATE TABLE IF NOT EXISTS events (
+    -- position es el orden en que el historial recibió los eventos
+    position BIGSERIAL PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    event_type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    -- Entidad a la que se refiere el evento (order, dish, user o
+    -- notification), tomada de su payload. NULL si no se pudo determinar.
+    aggregate_type TEXT,
+    aggregate_id UUID,
+    payload JSONB NOT NULL,
+    -- occurred_at es Event.Timestamp, en UTC
+    occurred_at TIMESTAMP NOT NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_occurred_at ON events (occurred_at, position);
+
+CREATE INDEX IF NOT EXISTS idx_events_type_occurred_at ON events (event_type, occurred_at);
+
+CREATE INDEX IF NOT EXISTS idx_events_aggregate_occurred_at ON events (aggregate_id, occurred_at)
+WHERE aggregate_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'la tabla events es de sólo inserción';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_append_only
+BEFORE UPDATE OR DELETE ON events
+FOR EACH ROW EXECUTE FUNCTION events_append_only();
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS projection_checkpoints;
+
+DROP TABLE IF EXISTS user_order_history;
+
+DROP TABLE IF EXISTS menu_by_date;
@@ -0,0 +1,80 @@
+-- Modelos de lectura del reader, mantenidos por las proyecciones de
+-- cmd/projector a partir del historial de eventos (tabla events).
+
+-- Platos del menú por fecha, una fila por plato. version es el instante del
+-- último evento aplicado; un evento anterior no sobrescribe la fila.
+CREATE TABLE IF NOT EXISTS menu_by_date (
+    dish_id UUID PRIMARY KEY,
+    available_on DATE NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    price NUMERIC(10, 2) NOT NULL,
+    prep_time_minutes INT NOT NULL,
+    version TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_menu_by_date_available_on ON menu_by_date (available_on, name);
+
+-- Órdenes de cada usuario con sus líneas. items guarda el nombre, la
+-- descripción y el precio de cada plato al momento de la orden.
+CREATE TABLE IF NOT EXISTS user_order_history (
+    order_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    status TEXT NOT NULL,
+    total NUMERIC(12, 2) NOT NULL,
+    items JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    version TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_order_history_user ON user_order_history (user_id, created_at DESC);
+
+-- Último evento del historial aplicado por cada proyección
+CREATE TABLE IF NOT EXISTS projection_checkpoints (
+    name TEXT PRIMARY KEY,
+    position BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- Carga inicial desde las tablas normalizadas, para los platos y órdenes
+-- anteriores al historial de eventos. Las proyecciones continúan desde el
+-- último evento ya guardado.
+INSERT INTO menu_by_date (dish_id, available_on, name, description, price, prep_time_minutes, version)
+SELECT id, available_on, name, COALESCE(description, ''), price, prep_time_minutes, COALESCE(updated_at, now())
+FROM dishes
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_order_history (order_id, user_id, status, total, items, created_at, updated_at, version)
+SELECT
+    o.id,
+    o.user_id,
+    o.status,
+    COALESCE(SUM(oi.unit_price * oi.quantity), 0),
+    COALESCE(
+        jsonb_agg(jsonb_build_object(
+            'id', oi.id,
+            'dish_id', oi.dish_id,
+            'dish_name', d.name,
+            'dish_description', COALESCE(d.description, ''),
+            'quantity', oi.quantity,
+            'unit_price', oi.unit_price::text,
+            'subtotal', (oi.unit_price * oi.quantity)::text,
+            'notes', COALESCE(oi.notes, ''),
+            'prep_time_minutes', d.prep_time_minutes
+        ) ORDER BY oi.created_at, oi.id) FILTER (WHERE oi.id IS NOT NULL),
+        '[]'::jsonb
+    ),
+    COALESCE(o.created_at, now()),
+    COALESCE(o.updated_at, now()),
+    COALESCE(o.updated_at, now())
+FROM orders o
+LEFT JOIN order_items oi ON oi.order_id = o.id
+LEFT JOIN dishes d ON d.id = oi.dish_id
+GROUP BY o.id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO projection_checkpoints (name, position)
+SELECT name, (SELECT COALESCE(MAX(position), 0) FROM events)
+FROM (VALUES ('menu_by_date'), ('user_order_history')) AS projections (name)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+ALTER TABLE projection_checkpoints DROP COLUMN IF EXISTS transaction_id;
+
+DROP INDEX IF EXISTS idx_events_transaction_position;
+
+ALTER TABLE events DROP COLUMN IF EXISTS transaction_id;
@@ -0,0 +1,20 @@
+-- Las posiciones de events (BIGSERIAL) se asignan al insertar, no al
+-- confirmar: una transacción puede confirmar un evento con una posición menor
+-- que la de otro que la proyección ya aplicó. transaction_id guarda la
+-- transacción que insertó el evento y las proyecciones leen en orden
+-- (transaction_id, position) sólo los eventos de transacciones anteriores a
+-- la más antigua todavía activa, que ya no pueden cambiar.
+--
+-- En las filas existentes toma el ID de la transacción de esta migración.
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS transaction_id BIGINT NOT NULL DEFAULT (pg_current_xact_id()::text::bigint);
+
+CREATE INDEX IF NOT EXISTS idx_events_transaction_position ON events (transaction_id, position);
+
+-- El checkpoint pasa a ser el par (transaction_id, position) del último
+-- evento aplicado
+ALTER TABLE projection_checkpoints
+    ADD COLUMN IF NOT EXISTS transaction_id BIGINT NOT NULL DEFAULT 0;
+
+UPDATE projection_checkpoints c
+SET transaction_id = COALESCE((SELECT e.transaction_id FROM events e WHERE e.position = c.position), 0);
@@ -0,0 +1,2 @@
+-- No se vuelve a crear orders.dish_id: una orden con varias líneas no tiene
+-- un único plato. Las líneas creadas por la migración se conservan.
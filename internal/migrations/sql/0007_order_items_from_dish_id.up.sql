@@ -0,0 +1,58 @@
+-- Las bases de datos creadas con el antiguo schema.sql tienen una orden por
+-- plato en orders.dish_id (NOT NULL) y 0001 no la cambia, por lo que
+-- CreateOrder falla al no indicar el plato. Esta migración pasa cada una a
+-- una línea de order_items (cantidad 1, al precio actual del plato, que es el
+-- único disponible) y elimina la columna. En las bases de datos creadas con
+-- las migraciones la columna no existe y no hace nada.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema()
+          AND table_name = 'orders'
+          AND column_name = 'dish_id'
+    ) THEN
+        RETURN;
+    END IF;
+
+    INSERT INTO order_items (id, order_id, dish_id, quantity, unit_price, created_at)
+    SELECT gen_random_uuid(), o.id, o.dish_id, 1, d.price, o.created_at
+    FROM orders o
+    JOIN dishes d ON d.id = o.dish_id
+    WHERE NOT EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = o.id);
+
+    ALTER TABLE orders DROP COLUMN dish_id;
+
+    -- 0005 cargó user_order_history antes de que estas órdenes tuvieran
+    -- líneas; se vuelven a cargar con sus líneas y su total
+    DELETE FROM user_order_history h
+    WHERE h.items = '[]'::jsonb
+      AND EXISTS (SELECT 1 FROM order_items oi WHERE oi.order_id = h.order_id);
+
+    INSERT INTO user_order_history (order_id, user_id, status, total, items, created_at, updated_at, version)
+    SELECT
+        o.id,
+        o.user_id,
+        o.status,
+        SUM(oi.unit_price * oi.quantity),
+        jsonb_agg(jsonb_build_object(
+            'id', oi.id,
+            'dish_id', oi.dish_id,
+            'dish_name', d.name,
+            'dish_description', COALESCE(d.description, ''),
+            'quantity', oi.quantity,
+            'unit_price', oi.unit_price::text,
+            'subtotal', (oi.unit_price * oi.quantity)::text,
+            'notes', COALESCE(oi.notes, ''),
+            'prep_time_minutes', d.prep_time_minutes
+        ) ORDER BY oi.created_at, oi.id),
+        COALESCE(o.created_at, now()),
+        COALESCE(o.updated_at, now()),
+        COALESCE(o.updated_at, now())
+    FROM orders o
+    JOIN order_items oi ON oi.order_id = o.id
+    JOIN dishes d ON d.id = oi.dish_id
+    GROUP BY o.id
+    ON CONFLICT DO NOTHING;
+END
+$$;
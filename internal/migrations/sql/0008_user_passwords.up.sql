@@ -0,0 +1,4 @@
+-- Contraseña de cada usuario (hash bcrypt), requerida por POST /users/token.
+-- Los usuarios existentes quedan sin contraseña y no pueden obtener tokens
+-- hasta que se les asigne una.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_hash TEXT;
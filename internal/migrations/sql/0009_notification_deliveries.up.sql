@@ -0,0 +1,9 @@
+-- Canales por los que ya se entregó cada notificación. El notifier los
+-- consulta antes de entregar para que un evento reentregado o reproducido no
+-- repita el email ni publique otro NotificationSent.
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    notification_id UUID NOT NULL,
+    channel TEXT NOT NULL,
+    delivered_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (notification_id, channel)
+);
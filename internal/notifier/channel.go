@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// Notification es un mensaje para el dueño de una orden, listo para
+// entregarse por cada canal
+type Notification struct {
+	ID      uuid.UUID
+	UserID  uuid.UUID
+	OrderID uuid.UUID
+	// Status es el estado de la orden que originó la notificación
+	Status string
+	// Name y Email son los datos de contacto del usuario
+	Name    string
+	Email   string
+	Subject string
+	Message string
+	SentAt  time.Time
+}
+
+// Channel entrega notificaciones por un medio, como la aplicación o el email
+type Channel interface {
+	// Name identifica el canal en los logs y en el evento NotificationSent
+	Name() string
+	// Send entrega la notificación. Puede llamarse más de una vez con la
+	// misma notificación si el evento que la originó se reentrega.
+	Send(ctx context.Context, notification Notification) error
+}
+
+// sqlStateUniqueViolation es el código SQLSTATE de una violación de unicidad
+const sqlStateUniqueViolation = "23505"
+
+// InAppChannel guarda la notificación en la tabla notifications, desde donde
+// la aplicación la muestra al usuario
+type InAppChannel struct {
+	db database.Querier
+}
+
+// NewInAppChannel crea el canal de notificaciones en la aplicación
+func NewInAppChannel(db database.Querier) *InAppChannel {
+	return &InAppChannel{db: db}
+}
+
+// Name implementa la interfaz Channel
+func (c *InAppChannel) Name() string {
+	return "in_app"
+}
+
+// Send implementa la interfaz Channel. El ID de la notificación se deriva del
+// evento, por lo que una notificación ya guardada no se duplica.
+func (c *InAppChannel) Send(ctx context.Context, notification Notification) error {
+	_, err := c.db.CreateNotification(ctx, database.CreateNotificationParams{
+		ID:      utils.ToPgUUID(notification.ID),
+		UserID:  utils.ToPgUUID(notification.UserID),
+		OrderID: utils.ToPgUUID(notification.OrderID),
+		Message: notification.Message,
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == sqlStateUniqueViolation {
+		return nil
+	}
+	return err
+}
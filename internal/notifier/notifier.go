@@ -0,0 +1,187 @@
+// Package notifier avisa a los clientes los cambios de estado de sus órdenes.
+// El Notifier escucha los eventos OrderStatusUpdated, genera el mensaje del
+// nuevo estado a partir de una plantilla, lo entrega por cada canal
+// configurado y publica NotificationSent a través del outbox.
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// HandleTimeout es el tiempo máximo para procesar un evento, incluyendo la
+// entrega por todos los canales
+const HandleTimeout = 30 * time.Second
+
+// ErrInvalidEvent indica que un evento nunca se podrá notificar (payload
+// inválido o usuario inexistente), por lo que no tiene sentido reintentarlo
+var ErrInvalidEvent = errors.New("evento inválido")
+
+// notificationNamespace deriva el ID de una notificación del ID del evento
+// que la originó, de modo que un evento reentregado genere el mismo ID
+var notificationNamespace = uuid.MustParse("fbe2cbed-5144-44f9-bea7-34578c30c676")
+
+// Notifier genera y entrega las notificaciones de las órdenes
+type Notifier struct {
+	db        database.Store
+	eventBus  *cqrs.EventBus
+	templates Templates
+	channels  []Channel
+}
+
+// New crea un Notifier que entrega las notificaciones por los canales dados
+func New(db database.Store, eventBus *cqrs.EventBus, templates Templates, channels ...Channel) *Notifier {
+	return &Notifier{
+		db:        db,
+		eventBus:  eventBus,
+		templates: templates,
+		channels:  channels,
+	}
+}
+
+// Run procesa los eventos OrderStatusUpdated hasta que se cancela el contexto.
+// Si un evento no se pudo notificar queda pendiente en el event bus y se
+// vuelve a entregar más tarde.
+func (n *Notifier) Run(ctx context.Context) {
+	filter := cqrs.EventFilter{Types: []string{cqrs.EventOrderStatusUpdated}}
+	unsubscribe := n.eventBus.SubscribeHandler(filter, func(event cqrs.Event) error {
+		handleCtx, cancel := context.WithTimeout(ctx, HandleTimeout)
+		defer cancel()
+
+		err := n.Handle(handleCtx, event)
+		if errors.Is(err, ErrInvalidEvent) {
+			log.Printf("Ignorando el evento %s: %v", event.ID, err)
+			return nil
+		}
+		return err
+	})
+	defer unsubscribe()
+
+	<-ctx.Done()
+}
+
+// Handle genera la notificación de un evento OrderStatusUpdated y la entrega.
+// Los estados sin plantilla se ignoran. Los canales se recorren en orden y el
+// primero que falla interrumpe la entrega y retorna error. Cada entrega se
+// registra en notification_deliveries con el ID de la notificación, que se
+// deriva del evento, por lo que al reintentar o reproducir el evento se
+// omiten los canales que ya la entregaron y, si no queda ninguno, tampoco se
+// publica otro NotificationSent.
+func (n *Notifier) Handle(ctx context.Context, event cqrs.Event) error {
+	var payload cqrs.OrderEventPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return fmt.Errorf("%w: payload inválido: %v", ErrInvalidEvent, err)
+	}
+	if _, ok := n.templates[payload.Status]; !ok {
+		return nil
+	}
+
+	orderID, err := uuid.Parse(payload.OrderID)
+	if err != nil {
+		return fmt.Errorf("%w: ID de orden inválido: %v", ErrInvalidEvent, err)
+	}
+	userID, err := uuid.Parse(payload.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: ID de usuario inválido: %v", ErrInvalidEvent, err)
+	}
+	user, err := n.db.GetUser(ctx, utils.ToPgUUID(userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: el usuario %s no existe", ErrInvalidEvent, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("error al obtener el usuario: %w", err)
+	}
+
+	subject, message, _, err := n.templates.Render(payload.Status, TemplateData{
+		Name:    user.Name,
+		OrderID: payload.OrderID,
+		ShortID: orderID.String()[:8],
+		Status:  payload.Status,
+		Items:   payload.Items,
+		Total:   payload.Total.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: error al generar el mensaje: %v", ErrInvalidEvent, err)
+	}
+
+	notification := Notification{
+		ID:      uuid.NewSHA1(notificationNamespace, []byte(event.ID)),
+		UserID:  userID,
+		OrderID: orderID,
+		Status:  payload.Status,
+		Name:    user.Name,
+		Email:   user.Email,
+		Subject: subject,
+		Message: message,
+		SentAt:  time.Now(),
+	}
+
+	previous, err := n.db.GetNotificationChannels(ctx, utils.ToPgUUID(notification.ID))
+	if err != nil {
+		return fmt.Errorf("error al obtener las entregas de la notificación %s: %w", notification.ID, err)
+	}
+
+	var delivered, sent []string
+	for _, channel := range n.channels {
+		delivered = append(delivered, channel.Name())
+		if slices.Contains(previous, channel.Name()) {
+			continue
+		}
+		if err := channel.Send(ctx, notification); err != nil {
+			// Registrar los canales que sí la entregaron para no repetirlos
+			// al reintentar
+			if recordErr := recordDeliveries(ctx, n.db, notification.ID, sent); recordErr != nil {
+				log.Printf("Error al registrar las entregas de la notificación %s: %v", notification.ID, recordErr)
+			}
+			return fmt.Errorf("error al entregar la notificación %s por %s: %w", notification.ID, channel.Name(), err)
+		}
+		sent = append(sent, channel.Name())
+	}
+	if len(sent) == 0 {
+		return nil
+	}
+
+	// Las entregas y NotificationSent se registran en la misma transacción.
+	// El evento se publica a través del outbox, que también lo agrega al
+	// historial de eventos
+	return n.db.ExecTx(ctx, func(q database.Querier) error {
+		if err := recordDeliveries(ctx, q, notification.ID, sent); err != nil {
+			return err
+		}
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventNotificationSent, "success", cqrs.NotificationEventPayload{
+			NotificationID: notification.ID.String(),
+			UserID:         payload.UserID,
+			OrderID:        payload.OrderID,
+			Status:         payload.Status,
+			Message:        message,
+			Channels:       delivered,
+			Timestamp:      notification.SentAt.Format(time.RFC3339),
+		})
+	})
+}
+
+// recordDeliveries registra que la notificación se entregó por los canales
+// dados
+func recordDeliveries(ctx context.Context, q database.Querier, id uuid.UUID, channels []string) error {
+	for _, channel := range channels {
+		err := q.RecordNotificationDelivery(ctx, database.RecordNotificationDeliveryParams{
+			NotificationID: utils.ToPgUUID(id),
+			Channel:        channel,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"time"
+)
+
+// SMTPConfig es la configuración del servidor de correo
+type SMTPConfig struct {
+	// Addr es host:puerto del servidor, por ejemplo "localhost:1025"
+	Addr string
+	// From es el remitente, por ejemplo "TheMenu <no-reply@themenu.local>"
+	From string
+	// Username y Password son opcionales; sin usuario no se autentica
+	Username string
+	Password string
+}
+
+// SMTPConfigFromEnv lee la configuración de SMTP_ADDR, SMTP_FROM,
+// SMTP_USERNAME y SMTP_PASSWORD. ok es false si SMTP_ADDR no está definida.
+func SMTPConfigFromEnv() (config SMTPConfig, ok bool) {
+	config = SMTPConfig{
+		Addr:     os.Getenv("SMTP_ADDR"),
+		From:     os.Getenv("SMTP_FROM"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+	}
+	if config.From == "" {
+		config.From = "TheMenu <no-reply@themenu.local>"
+	}
+	return config, config.Addr != ""
+}
+
+// SMTPChannel envía las notificaciones por email
+type SMTPChannel struct {
+	addr string
+	from *mail.Address
+	auth smtp.Auth
+}
+
+// NewSMTPChannel crea el canal de email
+func NewSMTPChannel(config SMTPConfig) (*SMTPChannel, error) {
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("remitente SMTP inválido: %w", err)
+	}
+	host, _, err := net.SplitHostPort(config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("dirección SMTP inválida: %w", err)
+	}
+
+	channel := &SMTPChannel{addr: config.Addr, from: from}
+	if config.Username != "" {
+		// PlainAuth sólo envía la contraseña sobre TLS o a localhost
+		channel.auth = smtp.PlainAuth("", config.Username, config.Password, host)
+	}
+	return channel, nil
+}
+
+// Name implementa la interfaz Channel
+func (c *SMTPChannel) Name() string {
+	return "email"
+}
+
+// Send implementa la interfaz Channel. Los usuarios sin email se omiten.
+func (c *SMTPChannel) Send(ctx context.Context, notification Notification) error {
+	if notification.Email == "" {
+		return nil
+	}
+	to := &mail.Address{Name: notification.Name, Address: notification.Email}
+
+	message, err := c.message(to, notification)
+	if err != nil {
+		return err
+	}
+
+	// smtp.SendMail no recibe un contexto; se ejecuta aparte para respetar
+	// la cancelación
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(c.addr, c.auth, c.from.Address, []string{to.Address}, message)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// message arma el email en texto plano codificado en quoted-printable
+func (c *SMTPChannel) message(to *mail.Address, notification Notification) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", notification.SentAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@themenu>\r\n", notification.ID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(notification.Message)); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,94 @@
+package notifier
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/rodrwan/themenu/internal/cqrs"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Templates son los mensajes de cada estado de una orden. Cada estado tiene
+// un archivo <estado>.tmpl que define las plantillas "subject" y "body"; los
+// estados sin archivo no generan notificaciones.
+type Templates map[string]*template.Template
+
+// TemplateData son los datos disponibles en las plantillas
+type TemplateData struct {
+	// Name es el nombre del usuario dueño de la orden
+	Name    string
+	OrderID string
+	// ShortID son los primeros 8 caracteres del ID de la orden
+	ShortID string
+	Status  string
+	Items   []cqrs.OrderItemPayload
+	// Total es el total de la orden como decimal, por ejemplo "15.99"
+	Total string
+}
+
+// DefaultTemplates retorna las plantillas incluidas en el binario
+func DefaultTemplates() (Templates, error) {
+	return LoadTemplates(defaultTemplates, "templates")
+}
+
+// LoadTemplates lee los archivos *.tmpl del directorio dir de fsys
+func LoadTemplates(fsys fs.FS, dir string) (Templates, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(Templates)
+	for _, entry := range entries {
+		name := entry.Name()
+		status, ok := strings.CutSuffix(name, ".tmpl")
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("error en la plantilla %s: %w", name, err)
+		}
+		for _, required := range []string{"subject", "body"} {
+			if tmpl.Lookup(required) == nil {
+				return nil, fmt.Errorf("la plantilla %s no define %q", name, required)
+			}
+		}
+		templates[status] = tmpl
+	}
+	return templates, nil
+}
+
+// Render genera el asunto y el cuerpo del mensaje de un estado. ok es false
+// si el estado no tiene plantilla.
+func (t Templates) Render(status string, data TemplateData) (subject, body string, ok bool, err error) {
+	tmpl, ok := t[status]
+	if !ok {
+		return "", "", false, nil
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", true, err
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := tmpl.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", true, err
+	}
+	body = strings.TrimSpace(buf.String())
+	return subject, body, true, nil
+}
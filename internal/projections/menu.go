@@ -0,0 +1,67 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// MenuProjection mantiene la tabla menu_by_date, que usa GetMenuQuery, a
+// partir de los eventos Dish*
+type MenuProjection struct{}
+
+// Name implementa la interfaz Projection
+func (MenuProjection) Name() string {
+	return "menu_by_date"
+}
+
+// EventTypes implementa la interfaz Projection
+func (MenuProjection) EventTypes() []string {
+	return []string{"Dish%"}
+}
+
+// Apply implementa la interfaz Projection
+func (MenuProjection) Apply(ctx context.Context, q database.Querier, event cqrs.Event) error {
+	var payload cqrs.DishEventPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	dishID, err := uuid.Parse(payload.DishID)
+	if err != nil {
+		return fmt.Errorf("%w: ID de plato inválido", ErrInvalidPayload)
+	}
+
+	switch event.Type {
+	case cqrs.EventDishCreated, cqrs.EventDishUpdated:
+		availableOn, err := time.Parse(time.RFC3339, payload.AvailableOn)
+		if err != nil {
+			return fmt.Errorf("%w: fecha inválida: %v", ErrInvalidPayload, err)
+		}
+		return q.UpsertMenuDish(ctx, database.UpsertMenuDishParams{
+			DishID:          utils.ToPgUUID(dishID),
+			AvailableOn:     utils.ToPgDate(availableOn),
+			Name:            payload.Name,
+			Description:     payload.Description,
+			Price:           utils.ToPgNumeric(payload.Price),
+			PrepTimeMinutes: int32(payload.PrepTimeMinutes),
+			Version:         utils.ToPgTimestamp(event.Timestamp),
+		})
+	case cqrs.EventDishDeleted:
+		return q.DeleteMenuDish(ctx, utils.ToPgUUID(dishID))
+	}
+	return nil
+}
+
+// Reset implementa la interfaz Projection
+func (MenuProjection) Reset(ctx context.Context, q database.Querier) error {
+	if err := q.DeleteAllMenuDishes(ctx); err != nil {
+		return err
+	}
+	return q.SeedMenuByDate(ctx)
+}
@@ -0,0 +1,101 @@
+package projections
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/cqrs/queries"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// OrderHistoryProjection mantiene la tabla user_order_history, que usa
+// GetUserOrdersQuery, a partir de los eventos Order*. Cada evento de orden
+// trae la orden completa, por lo que reemplaza la fila.
+type OrderHistoryProjection struct{}
+
+// Name implementa la interfaz Projection
+func (OrderHistoryProjection) Name() string {
+	return "user_order_history"
+}
+
+// EventTypes implementa la interfaz Projection
+func (OrderHistoryProjection) EventTypes() []string {
+	return []string{"Order%"}
+}
+
+// Apply implementa la interfaz Projection
+func (OrderHistoryProjection) Apply(ctx context.Context, q database.Querier, event cqrs.Event) error {
+	var payload cqrs.OrderEventPayload
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	orderID, err := uuid.Parse(payload.OrderID)
+	if err != nil {
+		return fmt.Errorf("%w: ID de orden inválido", ErrInvalidPayload)
+	}
+
+	if event.Type == cqrs.EventOrderDeleted {
+		return q.DeleteUserOrderHistory(ctx, utils.ToPgUUID(orderID))
+	}
+
+	userID, err := uuid.Parse(payload.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: ID de usuario inválido", ErrInvalidPayload)
+	}
+
+	// Los eventos anteriores a created_at en el payload usan el instante del
+	// evento como fecha de creación
+	createdAt := event.Timestamp
+	if payload.CreatedAt != "" {
+		if createdAt, err = time.Parse(time.RFC3339Nano, payload.CreatedAt); err != nil {
+			return fmt.Errorf("%w: fecha inválida: %v", ErrInvalidPayload, err)
+		}
+	}
+
+	lines := make([]queries.OrderLine, len(payload.Items))
+	for i, item := range payload.Items {
+		subtotal, err := item.UnitPrice.Mul(int64(item.Quantity))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+		lines[i] = queries.OrderLine{
+			ID:              item.ID,
+			DishID:          item.DishID,
+			DishName:        item.DishName,
+			DishDescription: item.DishDescription,
+			Quantity:        item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			Subtotal:        subtotal,
+			Notes:           item.Notes,
+			PrepTimeMinutes: item.PrepTimeMinutes,
+		}
+	}
+	items, err := json.Marshal(lines)
+	if err != nil {
+		return err
+	}
+
+	return q.UpsertUserOrderHistory(ctx, database.UpsertUserOrderHistoryParams{
+		OrderID:   utils.ToPgUUID(orderID),
+		UserID:    utils.ToPgUUID(userID),
+		Status:    payload.Status,
+		Total:     utils.ToPgNumeric(payload.Total),
+		Items:     items,
+		CreatedAt: utils.ToPgTimestamp(createdAt.UTC()),
+		UpdatedAt: utils.ToPgTimestamp(event.Timestamp),
+		Version:   utils.ToPgTimestamp(event.Timestamp),
+	})
+}
+
+// Reset implementa la interfaz Projection
+func (OrderHistoryProjection) Reset(ctx context.Context, q database.Querier) error {
+	if err := q.DeleteAllUserOrderHistory(ctx); err != nil {
+		return err
+	}
+	return q.SeedUserOrderHistory(ctx)
+}
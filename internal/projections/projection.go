@@ -0,0 +1,196 @@
+// Package projections mantiene los modelos de lectura del reader a partir del
+// historial de eventos. Cada proyección lee en orden los eventos de la tabla
+// events posteriores a su checkpoint, los aplica a sus tablas y avanza el
+// checkpoint en la misma transacción, por lo que cada evento se aplica una
+// sola vez. El checkpoint es el par (transaction_id, position) del último
+// evento aplicado y sólo se leen eventos de transacciones ya terminadas, de
+// modo que un evento confirmado tarde no queda detrás del checkpoint. Una
+// proyección se reconstruye volviendo a cargar sus tablas desde las tablas
+// normalizadas y aplicando el historial desde el inicio.
+package projections
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+const (
+	// PollInterval define cada cuánto se buscan eventos nuevos en el historial
+	PollInterval = 500 * time.Millisecond
+	// BatchSize define cuántos eventos se aplican por transacción
+	BatchSize = 500
+)
+
+// ErrInvalidPayload indica que un evento no se puede aplicar por su
+// contenido. El evento se omite para no detener la proyección.
+var ErrInvalidPayload = errors.New("payload inválido")
+
+// Projection mantiene un modelo de lectura a partir de eventos
+type Projection interface {
+	// Name identifica la proyección y su checkpoint
+	Name() string
+	// EventTypes son patrones LIKE de los tipos de evento que aplica
+	EventTypes() []string
+	// Apply aplica un evento con el Querier de la transacción del lote
+	Apply(ctx context.Context, q database.Querier, event cqrs.Event) error
+	// Reset borra el modelo de lectura y lo vuelve a cargar desde las tablas
+	// normalizadas, que también tienen los datos anteriores al historial
+	Reset(ctx context.Context, q database.Querier) error
+}
+
+// All retorna las proyecciones del reader
+func All() []Projection {
+	return []Projection{
+		MenuProjection{},
+		OrderHistoryProjection{},
+	}
+}
+
+// Runner mantiene al día un conjunto de proyecciones
+type Runner struct {
+	store       database.Store
+	projections []Projection
+}
+
+// NewRunner crea un Runner
+func NewRunner(store database.Store, projections ...Projection) *Runner {
+	return &Runner{
+		store:       store,
+		projections: projections,
+	}
+}
+
+// Run aplica los eventos nuevos cada PollInterval hasta que se cancela el
+// contexto
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, projection := range r.projections {
+				if _, err := CatchUp(ctx, r.store, projection); err != nil && ctx.Err() == nil {
+					log.Printf("Error al actualizar la proyección %s: %v", projection.Name(), err)
+				}
+			}
+		}
+	}
+}
+
+// CatchUp aplica los eventos posteriores al checkpoint de la proyección, en
+// lotes de BatchSize. Retorna la cantidad de eventos aplicados.
+func CatchUp(ctx context.Context, store database.Store, projection Projection) (int, error) {
+	total := 0
+	for {
+		applied := 0
+		err := store.ExecTx(ctx, func(q database.Querier) error {
+			position, err := lockCheckpoint(ctx, q, projection)
+			if err != nil {
+				return err
+			}
+			applied, _, err = applyBatch(ctx, q, projection, position)
+			return err
+		})
+		total += applied
+		if err != nil || applied < BatchSize {
+			return total, err
+		}
+	}
+}
+
+// Rebuild vuelve a cargar el modelo de lectura de la proyección con Reset y
+// aplica todo el historial en una sola transacción, por lo que el reader
+// sigue viendo el modelo anterior hasta que termina. Los eventos anteriores al
+// estado cargado no lo sobrescriben porque cada fila guarda su versión.
+// Retorna la cantidad de eventos aplicados.
+func Rebuild(ctx context.Context, store database.Store, projection Projection) (int, error) {
+	total := 0
+	err := store.ExecTx(ctx, func(q database.Querier) error {
+		if _, err := lockCheckpoint(ctx, q, projection); err != nil {
+			return err
+		}
+		if err := projection.Reset(ctx, q); err != nil {
+			return err
+		}
+		if err := q.SaveProjectionCheckpoint(ctx, database.SaveProjectionCheckpointParams{
+			Name: projection.Name(),
+		}); err != nil {
+			return err
+		}
+
+		var position checkpoint
+		for {
+			applied, next, err := applyBatch(ctx, q, projection, position)
+			if err != nil {
+				return err
+			}
+			total += applied
+			if applied < BatchSize {
+				return nil
+			}
+			position = next
+		}
+	})
+	return total, err
+}
+
+// checkpoint es el último evento aplicado por una proyección
+type checkpoint = database.LockProjectionCheckpointRow
+
+// lockCheckpoint retorna el checkpoint de la proyección, creándolo si no
+// existe, y lo bloquea hasta el fin de la transacción
+func lockCheckpoint(ctx context.Context, q database.Querier, projection Projection) (checkpoint, error) {
+	if err := q.EnsureProjectionCheckpoint(ctx, projection.Name()); err != nil {
+		return checkpoint{}, err
+	}
+	return q.LockProjectionCheckpoint(ctx, projection.Name())
+}
+
+// applyBatch aplica hasta BatchSize eventos posteriores a position y guarda el
+// nuevo checkpoint. Retorna la cantidad de eventos aplicados y el checkpoint.
+func applyBatch(ctx context.Context, q database.Querier, projection Projection, position checkpoint) (int, checkpoint, error) {
+	rows, err := q.ListEventsAfter(ctx, database.ListEventsAfterParams{
+		AfterTransactionID: position.TransactionID,
+		AfterPosition:      position.Position,
+		TypePatterns:       projection.EventTypes(),
+		RowLimit:           BatchSize,
+	})
+	if err != nil || len(rows) == 0 {
+		return 0, position, err
+	}
+
+	for _, row := range rows {
+		event := cqrs.Event{
+			ID:        utils.FromPgUUID(row.ID).String(),
+			Type:      row.EventType,
+			Status:    row.Status,
+			Payload:   string(row.Payload),
+			Timestamp: row.OccurredAt.Time,
+		}
+		if err := projection.Apply(ctx, q, event); err != nil {
+			if !errors.Is(err, ErrInvalidPayload) {
+				return 0, position, err
+			}
+			log.Printf("Proyección %s: se omite el evento %s (%s): %v", projection.Name(), event.ID, event.Type, err)
+		}
+	}
+
+	last := checkpoint{
+		TransactionID: rows[len(rows)-1].TransactionID,
+		Position:      rows[len(rows)-1].Position,
+	}
+	return len(rows), last, q.SaveProjectionCheckpoint(ctx, database.SaveProjectionCheckpointParams{
+		Name:          projection.Name(),
+		TransactionID: last.TransactionID,
+		Position:      last.Position,
+	})
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/utils"
 )
@@ -19,8 +23,16 @@ func NewDishHandler(db database.Querier) *DishHandler {
 	}
 }
 
-// ListDishes maneja la obtención de la lista de platos
+// ListDishes maneja la obtención de la lista de platos. Con ?from= y ?to=
+// (YYYY-MM-DD, ambas inclusive) retorna sólo los platos disponibles en ese
+// rango, ordenados por fecha.
 func (h *DishHandler) ListDishes(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if from != "" || to != "" {
+		h.listDishesByDateRange(c, from, to)
+		return
+	}
+
 	dishes, err := h.db.ListDishes(c.Request.Context())
 	if err != nil {
 		log.Printf("Error al obtener los platos: %v", err)
@@ -30,11 +42,18 @@ func (h *DishHandler) ListDishes(c *gin.Context) {
 
 	response := make([]gin.H, len(dishes))
 	for i, dish := range dishes {
+		price, err := utils.FromPgNumeric(dish.Price)
+		if err != nil {
+			log.Printf("Error al leer el precio del plato: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los platos"})
+			return
+		}
 		response[i] = gin.H{
 			"id":                utils.FromPgUUID(dish.ID).String(),
 			"name":              dish.Name,
 			"description":       dish.Description.String,
-			"price":             utils.ToFloat64(dish.Price),
+			"price":             price,
+			"currency":          price.Currency,
 			"prep_time_minutes": dish.PrepTimeMinutes,
 			"available_on":      dish.AvailableOn.Time,
 			"created_at":        dish.CreatedAt.Time,
@@ -44,3 +63,84 @@ func (h *DishHandler) ListDishes(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+func (h *DishHandler) listDishesByDateRange(c *gin.Context, from, to string) {
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido en from"})
+		return
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido en to"})
+		return
+	}
+
+	dishes, err := h.db.GetDishesByDateRange(c.Request.Context(), database.GetDishesByDateRangeParams{
+		FromDate: utils.ToPgDate(fromDate),
+		ToDate:   utils.ToPgDate(toDate),
+	})
+	if err != nil {
+		log.Printf("Error al obtener los platos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los platos"})
+		return
+	}
+
+	response := make([]gin.H, len(dishes))
+	for i, dish := range dishes {
+		if response[i], err = dishResponse(dish); err != nil {
+			log.Printf("Error al leer el precio del plato: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los platos"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDish maneja la obtención de un plato por su ID
+func (h *DishHandler) GetDish(c *gin.Context) {
+	dishID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plato inválido"})
+		return
+	}
+
+	dish, err := h.db.GetDish(c.Request.Context(), utils.ToPgUUID(dishID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plato no encontrado"})
+			return
+		}
+		log.Printf("Error al obtener el plato: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el plato"})
+		return
+	}
+
+	response, err := dishResponse(dish)
+	if err != nil {
+		log.Printf("Error al leer el precio del plato: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el plato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func dishResponse(dish database.Dish) (gin.H, error) {
+	price, err := utils.FromPgNumeric(dish.Price)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"id":                utils.FromPgUUID(dish.ID).String(),
+		"name":              dish.Name,
+		"description":       dish.Description.String,
+		"price":             price,
+		"currency":          price.Currency,
+		"prep_time_minutes": dish.PrepTimeMinutes,
+		"available_on":      dish.AvailableOn.Time,
+		"created_at":        dish.CreatedAt.Time,
+		"updated_at":        dish.UpdatedAt.Time,
+	}, nil
+}
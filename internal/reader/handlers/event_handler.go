@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
+	"github.com/rodrwan/themenu/internal/cqrs/queries"
+)
+
+type EventHandler struct {
+	queryBus queries.QueryDispatcher
+}
+
+func NewEventHandler(queryBus queries.QueryDispatcher) *EventHandler {
+	return &EventHandler{
+		queryBus: queryBus,
+	}
+}
+
+// ListEvents maneja la consulta del historial de eventos en orden
+// cronológico. Acepta los filtros ?type= (patrones separados por comas, como
+// "Order*"), ?aggregate_id=, ?from= y ?to= (fechas YYYY-MM-DD, ambas
+// inclusive, o RFC3339) y la paginación ?limit= y ?cursor= (el next_cursor de
+// la página anterior).
+func (h *EventHandler) ListEvents(c *gin.Context) {
+	query := queries.ListEventsQuery{
+		Cursor: c.Query("cursor"),
+		Limit:  queries.DefaultListEventsLimit,
+	}
+
+	for _, pattern := range strings.Split(c.Query("type"), ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			query.Types = append(query.Types, pattern)
+		}
+	}
+
+	var err error
+	if query.AggregateID, err = optionalUUID(c.Query("aggregate_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "aggregate_id inválido"})
+		return
+	}
+	if query.From, err = parseDateBound(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido en from"})
+		return
+	}
+	if query.To, err = parseDateBound(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido en to"})
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit inválido"})
+			return
+		}
+	}
+
+	result, err := queries.Dispatch[queries.ListEventsQuery, queries.EventPage](c.Request.Context(), h.queryBus, query)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrValidation) || errors.Is(err, queries.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error al obtener el historial de eventos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el historial de eventos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
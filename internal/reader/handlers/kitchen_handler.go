@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
+	"github.com/rodrwan/themenu/internal/cqrs/queries"
+)
+
+type KitchenHandler struct {
+	queryBus queries.QueryDispatcher
+}
+
+func NewKitchenHandler(queryBus queries.QueryDispatcher) *KitchenHandler {
+	return &KitchenHandler{
+		queryBus: queryBus,
+	}
+}
+
+// ListOrders maneja el listado de órdenes de todos los usuarios. Acepta los
+// filtros ?status= (separados por comas), ?dish_id=, ?user_id=, ?from= y ?to=
+// (fechas YYYY-MM-DD, ambas inclusive, o RFC3339) y la paginación ?limit= y
+// ?offset=.
+func (h *KitchenHandler) ListOrders(c *gin.Context) {
+	query := queries.ListOrdersQuery{
+		Limit: queries.DefaultListOrdersLimit,
+	}
+
+	if status := c.Query("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				query.Statuses = append(query.Statuses, s)
+			}
+		}
+	}
+
+	var err error
+	if query.DishID, err = optionalUUID(c.Query("dish_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plato inválido"})
+		return
+	}
+	if query.UserID, err = optionalUUID(c.Query("user_id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+	if query.From, err = parseDateBound(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido en from"})
+		return
+	}
+	if query.To, err = parseDateBound(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido en to"})
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit inválido"})
+			return
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset inválido"})
+			return
+		}
+	}
+
+	result, err := queries.Dispatch[queries.ListOrdersQuery, []queries.OrderDetail](c.Request.Context(), h.queryBus, query)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrValidation) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error al listar las órdenes: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las órdenes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// KitchenQueue maneja la obtención de la cola de cocina: las órdenes activas
+// de la más antigua a la más reciente, con su tiempo de preparación
+func (h *KitchenHandler) KitchenQueue(c *gin.Context) {
+	result, err := queries.Dispatch[queries.KitchenQueueQuery, []queries.KitchenTicket](c.Request.Context(), h.queryBus, queries.KitchenQueueQuery{})
+	if err != nil {
+		log.Printf("Error al obtener la cola de cocina: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la cola de cocina"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// optionalUUID parsea un UUID opcional; un valor vacío retorna uuid.Nil
+func optionalUUID(value string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(value)
+}
+
+// parseDateBound parsea un límite del rango de fechas. Una fecha sin hora
+// usada como límite superior incluye el día completo.
+func parseDateBound(value string, upper bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	// orders.created_at no guarda zona horaria y se escribe en UTC
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
+	"github.com/rodrwan/themenu/internal/cqrs/queries"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+type NotificationHandler struct {
+	queryBus queries.QueryDispatcher
+}
+
+func NewNotificationHandler(queryBus queries.QueryDispatcher) *NotificationHandler {
+	return &NotificationHandler{
+		queryBus: queryBus,
+	}
+}
+
+// ListNotifications maneja la obtención de las notificaciones del usuario
+// autenticado, de la más reciente a la más antigua. Acepta ?unread=true para
+// ver sólo las no leídas y la paginación ?limit= y ?cursor= (el next_cursor
+// de la página anterior).
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	pgUserID, ok := userID.(pgtype.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	query := queries.GetUserNotificationsQuery{
+		UserID: utils.FromPgUUID(pgUserID),
+		Cursor: c.Query("cursor"),
+		Limit:  queries.DefaultNotificationsLimit,
+	}
+
+	var err error
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit inválido"})
+			return
+		}
+	}
+	if unread := c.Query("unread"); unread != "" {
+		if query.UnreadOnly, err = strconv.ParseBool(unread); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unread inválido"})
+			return
+		}
+	}
+
+	result, err := queries.Dispatch[queries.GetUserNotificationsQuery, queries.NotificationPage](c.Request.Context(), h.queryBus, query)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrValidation) || errors.Is(err, queries.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error al obtener las notificaciones: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las notificaciones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
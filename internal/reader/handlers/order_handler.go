@@ -1,23 +1,29 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/auth"
 	"github.com/rodrwan/themenu/internal/cqrs/queries"
+	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/utils"
 )
 
 type OrderHandler struct {
 	queryBus queries.QueryDispatcher
+	db       database.Querier
 }
 
-func NewOrderHandler(queryBus queries.QueryDispatcher) *OrderHandler {
+func NewOrderHandler(queryBus queries.QueryDispatcher, db database.Querier) *OrderHandler {
 	return &OrderHandler{
 		queryBus: queryBus,
+		db:       db,
 	}
 }
 
@@ -32,15 +38,15 @@ func (h *OrderHandler) GetMenu(c *gin.Context) {
 	}
 
 	// Crear y ejecutar la consulta
-	query := &queries.GetMenuQuery{
+	query := queries.GetMenuQuery{
 		Date:    date,
 		Queries: nil, // Se establecerá en el handler
 	}
 
-	result, err := h.queryBus.Dispatch(query)
+	result, err := queries.Dispatch[queries.GetMenuQuery, []queries.MenuItem](c.Request.Context(), h.queryBus, query)
 	if err != nil {
-		switch err {
-		case queries.ErrMenuNotFound:
+		switch {
+		case errors.Is(err, queries.ErrMenuNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "No hay menú disponible para esta fecha"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el menú"})
@@ -79,11 +85,11 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 	}
 
 	// Crear y ejecutar la consulta
-	query := &queries.GetUserOrdersQuery{
+	query := queries.GetUserOrdersQuery{
 		UserID: userUUID,
 	}
 
-	result, err := h.queryBus.Dispatch(query)
+	result, err := queries.Dispatch[queries.GetUserOrdersQuery, []queries.OrderDetail](c.Request.Context(), h.queryBus, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las órdenes"})
 		return
@@ -91,3 +97,50 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+// GetOrder maneja la obtención de una orden por su ID. El dueño de la orden
+// siempre puede verla; los demás usuarios necesitan update_order_status. A
+// quien no puede verla se le responde 404 para no revelar que existe.
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de orden inválido"})
+		return
+	}
+
+	query := queries.GetOrderQuery{
+		OrderID: orderID,
+	}
+
+	order, err := queries.Dispatch[queries.GetOrderQuery, queries.OrderDetail](c.Request.Context(), h.queryBus, query)
+	if err != nil {
+		switch {
+		case errors.Is(err, queries.ErrOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Orden no encontrada"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la orden"})
+		}
+		return
+	}
+
+	ownerID, err := uuid.Parse(order.UserID)
+	if err != nil {
+		log.Printf("Error al leer el dueño de la orden %s: %v", order.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la orden"})
+		return
+	}
+
+	if currentUserID, _ := c.Get("user_id"); currentUserID != utils.ToPgUUID(ownerID) {
+		permissions, err := auth.Permissions(c, h.db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar permisos"})
+			return
+		}
+		if !permissions.Has(auth.PermUpdateOrderStatus) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Orden no encontrada"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, order)
+}
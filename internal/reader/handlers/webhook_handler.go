@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+	"github.com/rodrwan/themenu/internal/webhooks"
+)
+
+const (
+	// defaultDeliveriesLimit es la cantidad de entregas por página si no se
+	// indica
+	defaultDeliveriesLimit = 50
+	// maxDeliveriesLimit es la cantidad máxima de entregas por página
+	maxDeliveriesLimit = 200
+)
+
+type WebhookHandler struct {
+	db database.Querier
+}
+
+func NewWebhookHandler(db database.Querier) *WebhookHandler {
+	return &WebhookHandler{
+		db: db,
+	}
+}
+
+// ListSubscriptions maneja la obtención de las suscripciones de webhooks
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := h.db.ListWebhookSubscriptions(c.Request.Context())
+	if err != nil {
+		log.Printf("Error al obtener los webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los webhooks"})
+		return
+	}
+
+	response := make([]gin.H, len(subscriptions))
+	for i, subscription := range subscriptions {
+		response[i] = webhookSubscriptionResponse(subscription)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSubscription maneja la obtención de una suscripción de webhook
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de webhook inválido"})
+		return
+	}
+
+	subscription, err := h.db.GetWebhookSubscription(c.Request.Context(), utils.ToPgUUID(subscriptionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook no encontrado"})
+			return
+		}
+		log.Printf("Error al obtener el webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookSubscriptionResponse(subscription))
+}
+
+// ListDeliveries maneja el registro de entregas, de la más reciente a la más
+// antigua. Acepta ?subscription_id=, ?status= (pending, delivered o dead; dead
+// es la lista de dead-letter) y la paginación ?limit= y ?cursor= (el
+// next_cursor de la página anterior).
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	limit := defaultDeliveriesLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxDeliveriesLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit debe estar entre 1 y 200"})
+			return
+		}
+		limit = parsed
+	}
+
+	params := database.ListWebhookDeliveriesParams{
+		// Se pide una fila extra para saber si hay otra página
+		RowLimit: int32(limit + 1),
+	}
+	if value := c.Query("subscription_id"); value != "" {
+		subscriptionID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de webhook inválido"})
+			return
+		}
+		params.SubscriptionID = utils.ToPgUUID(subscriptionID)
+	}
+	if status := c.Query("status"); status != "" {
+		if status != webhooks.StatusPending && status != webhooks.StatusDelivered && status != webhooks.StatusDead {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status debe ser pending, delivered o dead"})
+			return
+		}
+		params.Status = utils.ToPgText(status)
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		createdAt, id, ok := decodeDeliveryCursor(cursor)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor inválido"})
+			return
+		}
+		params.CursorCreatedAt = utils.ToPgTimestamp(createdAt)
+		params.CursorID = utils.ToPgUUID(id)
+	}
+
+	deliveries, err := h.db.ListWebhookDeliveries(c.Request.Context(), params)
+	if err != nil {
+		log.Printf("Error al obtener las entregas de webhooks: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las entregas"})
+		return
+	}
+
+	response := gin.H{}
+	if len(deliveries) > limit {
+		last := deliveries[limit-1]
+		response["next_cursor"] = encodeDeliveryCursor(last.CreatedAt.Time, utils.FromPgUUID(last.ID))
+		deliveries = deliveries[:limit]
+	}
+	items := make([]gin.H, len(deliveries))
+	for i, delivery := range deliveries {
+		items[i] = webhookDeliveryResponse(delivery)
+	}
+	response["deliveries"] = items
+	c.JSON(http.StatusOK, response)
+}
+
+// GetDelivery maneja la obtención de una entrega con su cuerpo y el
+// resultado de cada intento
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	deliveryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de entrega inválido"})
+		return
+	}
+
+	delivery, err := h.db.GetWebhookDelivery(c.Request.Context(), utils.ToPgUUID(deliveryID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entrega no encontrada"})
+			return
+		}
+		log.Printf("Error al obtener la entrega: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la entrega"})
+		return
+	}
+
+	attempts, err := h.db.ListWebhookDeliveryAttempts(c.Request.Context(), delivery.ID)
+	if err != nil {
+		log.Printf("Error al obtener los intentos de la entrega: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la entrega"})
+		return
+	}
+
+	history := make([]gin.H, len(attempts))
+	for i, attempt := range attempts {
+		history[i] = gin.H{
+			"status_code":  nullableInt(attempt.StatusCode),
+			"error":        attempt.Error.String,
+			"duration_ms":  attempt.DurationMs,
+			"attempted_at": attempt.AttemptedAt.Time,
+		}
+	}
+
+	response := webhookDeliveryResponse(delivery)
+	response["payload"] = json.RawMessage(delivery.Payload)
+	response["attempt_log"] = history
+	c.JSON(http.StatusOK, response)
+}
+
+// webhookSubscriptionResponse construye la respuesta JSON de una suscripción,
+// sin el secreto
+func webhookSubscriptionResponse(subscription database.WebhookSubscription) gin.H {
+	return gin.H{
+		"id":          utils.FromPgUUID(subscription.ID).String(),
+		"url":         subscription.Url,
+		"event_types": subscription.EventTypes,
+		"active":      subscription.Active,
+		"created_at":  subscription.CreatedAt.Time,
+		"updated_at":  subscription.UpdatedAt.Time,
+	}
+}
+
+// webhookDeliveryResponse construye la respuesta JSON de una entrega
+func webhookDeliveryResponse(delivery database.WebhookDelivery) gin.H {
+	response := gin.H{
+		"id":               utils.FromPgUUID(delivery.ID).String(),
+		"subscription_id":  utils.FromPgUUID(delivery.SubscriptionID).String(),
+		"event_id":         utils.FromPgUUID(delivery.EventID).String(),
+		"event_type":       delivery.EventType,
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"last_status_code": nullableInt(delivery.LastStatusCode),
+		"last_error":       delivery.LastError.String,
+		"created_at":       delivery.CreatedAt.Time,
+		"delivered_at":     nil,
+	}
+	if delivery.Status == webhooks.StatusPending {
+		response["next_attempt_at"] = delivery.NextAttemptAt.Time
+	}
+	if delivery.DeliveredAt.Valid {
+		response["delivered_at"] = delivery.DeliveredAt.Time
+	}
+	return response
+}
+
+// nullableInt retorna nil para un entero NULL
+func nullableInt(value pgtype.Int4) any {
+	if !value.Valid {
+		return nil
+	}
+	return value.Int32
+}
+
+// encodeDeliveryCursor codifica la posición de una entrega como un cursor
+// opaco
+func encodeDeliveryCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeDeliveryCursor(cursor string) (time.Time, uuid.UUID, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, false
+	}
+	createdAtText, idText, ok := strings.Cut(string(data), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, false
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtText)
+	if err != nil {
+		return time.Time{}, uuid.Nil, false
+	}
+	id, err := uuid.Parse(idText)
+	if err != nil {
+		return time.Time{}, uuid.Nil, false
+	}
+	return createdAt, id, true
+}
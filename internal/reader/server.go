@@ -1,26 +1,50 @@
 package reader
 
 import (
+	"log"
+	"net/http"
+	"sort"
+
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/auth"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
 	"github.com/rodrwan/themenu/internal/cqrs/queries"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/reader/handlers"
 	"github.com/rodrwan/themenu/internal/reader/middleware"
+	"github.com/rodrwan/themenu/internal/utils"
 )
 
+// RequiredQueries son las consultas que despachan los handlers del servidor.
+// El bus debe tener un handler para cada una antes de iniciar.
+var RequiredQueries = []queries.Requirement{
+	queries.Require[queries.GetMenuQuery, []queries.MenuItem](),
+	queries.Require[queries.GetUserOrdersQuery, []queries.OrderDetail](),
+	queries.Require[queries.GetOrderQuery, queries.OrderDetail](),
+	queries.Require[queries.ListOrdersQuery, []queries.OrderDetail](),
+	queries.Require[queries.KitchenQueueQuery, []queries.KitchenTicket](),
+	queries.Require[queries.GetUserNotificationsQuery, queries.NotificationPage](),
+	queries.Require[queries.ListEventsQuery, queries.EventPage](),
+}
+
 // Server representa el servidor HTTP
 type Server struct {
 	router   *gin.Engine
 	queryBus queries.QueryDispatcher
 	db       database.Querier
+	tokens   *auth.Manager
+	latency  *pipeline.LatencyStats
 }
 
 // NewServer crea una nueva instancia del servidor
-func NewServer(queryBus queries.QueryDispatcher, db database.Querier) *Server {
+func NewServer(queryBus queries.QueryDispatcher, db database.Querier, tokens *auth.Manager, latency *pipeline.LatencyStats) *Server {
 	server := &Server{
 		router:   gin.Default(),
 		queryBus: queryBus,
 		db:       db,
+		tokens:   tokens,
+		latency:  latency,
 	}
 
 	server.setupRoutes()
@@ -33,25 +57,89 @@ func (s *Server) setupRoutes() {
 	s.router.Use(middleware.LoggerMiddleware())
 
 	// Aplicar middleware de autenticación para el resto de rutas
-	s.router.Use(middleware.AuthMiddleware(s.db))
+	s.router.Use(auth.Middleware(s.tokens, s.db))
 
-	orderHandler := handlers.NewOrderHandler(s.queryBus)
+	orderHandler := handlers.NewOrderHandler(s.queryBus, s.db)
 	// Rutas protegidas
 	menu := s.router.Group("/menu")
 	{
-		menu.GET("", orderHandler.GetMenu)
+		menu.GET("", auth.RequirePermission(s.db, auth.PermViewMenu), orderHandler.GetMenu)
 	}
 	// Rutas de órdenes
 	orders := s.router.Group("/orders")
 	{
-		orders.GET("", orderHandler.GetUserOrders)
+		orders.GET("", auth.RequirePermission(s.db, auth.PermPlaceOrder), orderHandler.GetUserOrders)
+		// Cada usuario ve sus propias órdenes; ver las de otros requiere
+		// update_order_status (ver OrderHandler.GetOrder)
+		orders.GET("/:id", orderHandler.GetOrder)
+	}
+	// Rutas de cocina y administración
+	kitchenHandler := handlers.NewKitchenHandler(s.queryBus)
+	kitchen := s.router.Group("/kitchen", auth.RequirePermission(s.db, auth.PermUpdateOrderStatus))
+	{
+		kitchen.GET("/orders", kitchenHandler.ListOrders)
+		kitchen.GET("/queue", kitchenHandler.KitchenQueue)
 	}
 	// Rutas de platos
 	dishHandler := handlers.NewDishHandler(s.db)
 	dishes := s.router.Group("/dishes")
 	{
-		dishes.GET("", dishHandler.ListDishes)
+		dishes.GET("", auth.RequirePermission(s.db, auth.PermViewMenu), dishHandler.ListDishes)
+		dishes.GET("/:id", auth.RequirePermission(s.db, auth.PermViewMenu), dishHandler.GetDish)
+	}
+
+	// Notificaciones del usuario autenticado
+	notificationHandler := handlers.NewNotificationHandler(s.queryBus)
+	s.router.GET("/notifications", notificationHandler.ListNotifications)
+
+	// Webhooks salientes: suscripciones y registro de entregas
+	webhookHandler := handlers.NewWebhookHandler(s.db)
+	webhooks := s.router.Group("/webhooks", auth.RequirePermission(s.db, auth.PermManageWebhooks))
+	{
+		webhooks.GET("/subscriptions", webhookHandler.ListSubscriptions)
+		webhooks.GET("/subscriptions/:id", webhookHandler.GetSubscription)
+		webhooks.GET("/deliveries", webhookHandler.ListDeliveries)
+		webhooks.GET("/deliveries/:id", webhookHandler.GetDelivery)
+	}
+
+	// Historial de eventos, para auditoría y diagnóstico
+	eventHandler := handlers.NewEventHandler(s.queryBus)
+	s.router.GET("/events", auth.RequirePermission(s.db, auth.PermManageUsers), eventHandler.ListEvents)
+
+	// Permisos del usuario autenticado, para que las interfaces muestren sólo
+	// lo que puede hacer
+	s.router.GET("/me", s.handleMe)
+
+	// Latencias acumuladas por el bus, para diagnóstico
+	s.router.GET("/debug/latency", auth.RequirePermission(s.db, auth.PermManageUsers), s.handleLatency)
+}
+
+// handleMe responde con el ID y los permisos efectivos del usuario autenticado
+func (s *Server) handleMe(c *gin.Context) {
+	permissions, err := auth.Permissions(c, s.db)
+	if err != nil {
+		log.Printf("Error al obtener los permisos del usuario: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los permisos"})
+		return
 	}
+
+	names := make([]string, 0, len(permissions))
+	for name := range permissions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	userID, _ := c.Get("user_id")
+	pgUserID, _ := userID.(pgtype.UUID)
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     utils.FromPgUUID(pgUserID).String(),
+		"permissions": names,
+	})
+}
+
+// handleLatency responde con las latencias acumuladas por tipo de mensaje
+func (s *Server) handleLatency(c *gin.Context) {
+	c.JSON(http.StatusOK, s.latency.Snapshot())
 }
 
 // Start inicia el servidor
@@ -13,3 +13,11 @@ func ToPgDate(t time.Time) pgtype.Date {
 		Valid: true,
 	}
 }
+
+// ToPgTimestamp convierte un time.Time a pgtype.Timestamp
+func ToPgTimestamp(t time.Time) pgtype.Timestamp {
+	return pgtype.Timestamp{
+		Time:  t,
+		Valid: true,
+	}
+}
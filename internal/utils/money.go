@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency es la moneda de los montos guardados en la base de datos,
+// cuyas columnas de precio no registran moneda
+var DefaultCurrency = "USD"
+
+// currencyDigits es la cantidad de decimales de las monedas que no usan dos
+// (ISO 4217)
+var currencyDigits = map[string]int{
+	"CLP": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"PYG": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"TND": 3,
+}
+
+var (
+	ErrInvalidMoney     = errors.New("monto inválido")
+	ErrMoneyPrecision   = errors.New("el monto tiene más decimales de los que admite la moneda")
+	ErrMoneyOverflow    = errors.New("el monto está fuera de rango")
+	ErrCurrencyMismatch = errors.New("los montos tienen monedas distintas")
+)
+
+// Money es un monto exacto en unidades menores de una moneda ISO 4217: 15.99
+// USD es Money{Amount: 1599, Currency: "USD"}. En JSON se representa como un
+// decimal en un string ("15.99") para no perder precisión; la moneda va en un
+// campo currency junto al monto.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// NewMoney crea un monto a partir de unidades menores
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parsea un decimal como "15.99" en la moneda indicada. Rechaza
+// los montos con más decimales de los que admite la moneda.
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	digits := CurrencyDigits(currency)
+
+	// Se acepta un único signo opcional
+	negative := false
+	switch {
+	case strings.HasPrefix(value, "-"):
+		negative = true
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+	}
+
+	// Los ceros a la derecha no cambian el valor
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > digits {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyPrecision, value)
+	}
+	fraction += strings.Repeat("0", digits-len(fraction))
+
+	minor := whole + fraction
+	if minor == "" {
+		minor = "0"
+	}
+	amount, err := strconv.ParseInt(minor, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyOverflow, value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// CurrencyDigits retorna la cantidad de decimales de una moneda
+func CurrencyDigits(currency string) int {
+	if digits, ok := currencyDigits[currency]; ok {
+		return digits
+	}
+	return 2
+}
+
+// Add suma dos montos de la misma moneda. Un monto sin moneda (el valor cero
+// de Money) toma la del otro.
+func (m Money) Add(other Money) (Money, error) {
+	switch {
+	case m.Currency == "":
+		m.Currency = other.Currency
+	case other.Currency != "" && other.Currency != m.Currency:
+		return Money{}, fmt.Errorf("%w: %s y %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+	m.Amount = sum
+	return m, nil
+}
+
+// Mul multiplica el monto por una cantidad entera
+func (m Money) Mul(quantity int64) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(quantity))
+	if !product.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	m.Amount = product.Int64()
+	return m, nil
+}
+
+// String retorna el monto como decimal, sin la moneda: "15.99"
+func (m Money) String() string {
+	digits := CurrencyDigits(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	text := new(big.Int).Abs(big.NewInt(amount)).String()
+	if digits == 0 {
+		return sign + text
+	}
+	if len(text) <= digits {
+		text = strings.Repeat("0", digits-len(text)+1) + text
+	}
+	return sign + text[:len(text)-digits] + "." + text[len(text)-digits:]
+}
+
+// MarshalJSON representa el monto como un decimal en un string
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON acepta un decimal en un string o un número JSON, y usa
+// DefaultCurrency si el monto no tiene moneda. El número se parsea desde su
+// texto, por lo que tampoco pierde precisión.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := string(bytes.TrimSpace(data))
+	if value == "null" {
+		return nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(value, "eE") {
+		return fmt.Errorf("%w: %s", ErrInvalidMoney, value)
+	}
+
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	parsed, err := ParseMoney(value, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		err      error
+	}{
+		{value: "15.99", currency: "USD", want: Money{Amount: 1599, Currency: "USD"}},
+		{value: "15", currency: "USD", want: Money{Amount: 1500, Currency: "USD"}},
+		{value: ".5", currency: "USD", want: Money{Amount: 50, Currency: "USD"}},
+		{value: "15.90000", currency: "USD", want: Money{Amount: 1590, Currency: "USD"}},
+		{value: " -1.25 ", currency: "USD", want: Money{Amount: -125, Currency: "USD"}},
+		{value: "+5", currency: "USD", want: Money{Amount: 500, Currency: "USD"}},
+		{value: "1500", currency: "CLP", want: Money{Amount: 1500, Currency: "CLP"}},
+		{value: "1.234", currency: "KWD", want: Money{Amount: 1234, Currency: "KWD"}},
+		{value: "15.999", currency: "USD", err: ErrMoneyPrecision},
+		{value: "1500.5", currency: "CLP", err: ErrMoneyPrecision},
+		{value: "-+5", currency: "USD", err: ErrInvalidMoney},
+		{value: "+-5", currency: "USD", err: ErrInvalidMoney},
+		{value: "--5", currency: "USD", err: ErrInvalidMoney},
+		{value: "", currency: "USD", err: ErrInvalidMoney},
+		{value: ".", currency: "USD", err: ErrInvalidMoney},
+		{value: "1,5", currency: "USD", err: ErrInvalidMoney},
+		{value: "1e3", currency: "USD", err: ErrInvalidMoney},
+		{value: "99999999999999999999", currency: "USD", err: ErrMoneyOverflow},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value, tt.currency)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ParseMoney(%q, %q) error = %v, want %v", tt.value, tt.currency, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %q) error = %v", tt.value, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q, %q) = %+v, want %+v", tt.value, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Money
+		want Money
+		err  error
+	}{
+		{name: "misma moneda", a: NewMoney(1599, "USD"), b: NewMoney(401, "USD"), want: NewMoney(2000, "USD")},
+		{name: "negativo", a: NewMoney(500, "USD"), b: NewMoney(-750, "USD"), want: NewMoney(-250, "USD")},
+		{name: "cero sin moneda", a: Money{}, b: NewMoney(100, "CLP"), want: NewMoney(100, "CLP")},
+		{name: "otro sin moneda", a: NewMoney(100, "CLP"), b: Money{}, want: NewMoney(100, "CLP")},
+		{name: "monedas distintas", a: NewMoney(100, "USD"), b: NewMoney(100, "CLP"), err: ErrCurrencyMismatch},
+		{name: "overflow", a: NewMoney(math.MaxInt64, "USD"), b: NewMoney(1, "USD"), err: ErrMoneyOverflow},
+		{name: "underflow", a: NewMoney(math.MinInt64, "USD"), b: NewMoney(-1, "USD"), err: ErrMoneyOverflow},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.a.Add(tt.b)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		quantity int64
+		want     Money
+		err      error
+	}{
+		{name: "cantidad", m: NewMoney(1599, "USD"), quantity: 3, want: NewMoney(4797, "USD")},
+		{name: "cero", m: NewMoney(1599, "USD"), quantity: 0, want: NewMoney(0, "USD")},
+		{name: "negativo", m: NewMoney(-250, "USD"), quantity: 2, want: NewMoney(-500, "USD")},
+		{name: "overflow", m: NewMoney(math.MaxInt64/2+1, "USD"), quantity: 2, err: ErrMoneyOverflow},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.m.Mul(tt.quantity)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{m: NewMoney(1599, "USD"), want: "15.99"},
+		{m: NewMoney(5, "USD"), want: "0.05"},
+		{m: NewMoney(-5, "USD"), want: "-0.05"},
+		{m: NewMoney(0, "USD"), want: "0.00"},
+		{m: NewMoney(1500, "CLP"), want: "1500"},
+		{m: NewMoney(1234, "KWD"), want: "1.234"},
+		{m: NewMoney(math.MinInt64, "USD"), want: "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want Money
+		err  error
+	}{
+		{data: `"15.99"`, want: NewMoney(1599, DefaultCurrency)},
+		{data: `"-0.50"`, want: NewMoney(-50, DefaultCurrency)},
+		{data: `15.99`, want: NewMoney(1599, DefaultCurrency)},
+		{data: `15`, want: NewMoney(1500, DefaultCurrency)},
+		{data: `1.599e1`, err: ErrInvalidMoney},
+		{data: `"15.999"`, err: ErrMoneyPrecision},
+		{data: `"-+5"`, err: ErrInvalidMoney},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Unmarshal(%s) error = %v, want %v", tt.data, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.data, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.data, got, tt.want)
+		}
+
+		// MarshalJSON genera el decimal en un string
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("Marshal(%+v) error = %v", got, err)
+			continue
+		}
+		if want := `"` + got.String() + `"`; string(data) != want {
+			t.Errorf("Marshal(%+v) = %s, want %s", got, data, want)
+		}
+	}
+}
@@ -1,22 +1,46 @@
 package utils
 
 import (
-	"math"
+	"fmt"
+	"math/big"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// ToFloat64 convierte un pgtype.Numeric a float64
-func ToFloat64(n pgtype.Numeric) float64 {
+// FromPgNumeric convierte un pgtype.Numeric a Money en DefaultCurrency sin
+// pasar por float64. Retorna error si el valor tiene más decimales de los que
+// admite la moneda o no cabe en int64.
+func FromPgNumeric(n pgtype.Numeric) (Money, error) {
 	if !n.Valid {
-		return 0
+		return Money{Currency: DefaultCurrency}, nil
 	}
-	return float64(n.Int.Int64()) / math.Pow10(int(-n.Exp))
+	if n.NaN || n.InfinityModifier != pgtype.Finite {
+		return Money{}, fmt.Errorf("%w: %v", ErrInvalidMoney, n)
+	}
+
+	// El valor es Int * 10^Exp; en unidades menores es Int * 10^(Exp+digits)
+	amount := new(big.Int).Set(n.Int)
+	shift := int(n.Exp) + CurrencyDigits(DefaultCurrency)
+	if shift >= 0 {
+		amount.Mul(amount, pow10(shift))
+	} else {
+		var remainder big.Int
+		amount.QuoRem(amount, pow10(-shift), &remainder)
+		if remainder.Sign() != 0 {
+			return Money{}, ErrMoneyPrecision
+		}
+	}
+	if !amount.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: amount.Int64(), Currency: DefaultCurrency}, nil
 }
 
-// ToPgNumeric convierte un float64 a pgtype.Numeric
-func ToPgNumeric(f float64) pgtype.Numeric {
-	var n pgtype.Numeric
-	n.Scan(f)
-	return n
+// ToPgNumeric convierte un Money a pgtype.Numeric sin pasar por float64
+func ToPgNumeric(m Money) pgtype.Numeric {
+	return pgtype.Numeric{
+		Int:   big.NewInt(m.Amount),
+		Exp:   int32(-CurrencyDigits(m.Currency)),
+		Valid: true,
+	}
 }
@@ -0,0 +1,297 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodrwan/themenu/internal/utils"
+	"github.com/rodrwan/themenu/internal/web/templates"
+)
+
+// handleAdminDishes renderiza la grilla de platos de la semana de ?week=
+// (cualquier fecha de la semana, YYYY-MM-DD) o de la semana actual
+func (s *Server) handleAdminDishes(c *fiber.Ctx) error {
+	start := weekStart(parseDateOr(c.Query("week"), today()))
+
+	message := ""
+	if cloned, err := strconv.Atoi(c.Query("cloned")); err == nil {
+		message = fmt.Sprintf("Se copiaron %d platos de la semana anterior", cloned)
+	}
+	return s.renderAdminWeek(c, start, message, "")
+}
+
+// handleAdminCloneWeek copia los platos de la semana anterior a la semana del
+// formulario
+func (s *Server) handleAdminCloneWeek(c *fiber.Ctx) error {
+	start := weekStart(parseDateOr(c.FormValue("week_start"), today()))
+
+	cloned, err := s.apiClient.CloneWeek(c.UserContext(), sessionToken(c), start)
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		c.Status(fiber.StatusBadGateway)
+		return s.renderAdminWeek(c, start, "", apiErrorMessage(err, "No se pudo copiar la semana anterior"))
+	}
+
+	return c.Redirect(fmt.Sprintf("/admin/dishes?week=%s&cloned=%d", start.Format("2006-01-02"), cloned), fiber.StatusSeeOther)
+}
+
+// handleAdminNewDish renderiza el formulario de un plato nuevo. ?date= fija la
+// fecha y ?copy= toma los datos de otro plato, para asignarlo a otro día.
+func (s *Server) handleAdminNewDish(c *fiber.Ctx) error {
+	form := templates.DishForm{
+		AvailableOn: parseDateOr(c.Query("date"), today()).Format("2006-01-02"),
+	}
+
+	if copyID := c.Query("copy"); copyID != "" {
+		dish, err := s.apiClient.GetDish(c.UserContext(), sessionToken(c), copyID)
+		if err != nil {
+			if IsUnauthorized(err) {
+				return s.unauthenticated(c)
+			}
+			form.ErrorMessage = apiErrorMessage(err, "No se pudo obtener el plato a copiar")
+		} else {
+			form = dishForm(dish)
+			form.ID = ""
+			if date := c.Query("date"); date != "" {
+				form.AvailableOn = date
+			}
+		}
+	}
+
+	return render(c, templates.AdminDishForm(form))
+}
+
+// handleAdminCreateDish crea un plato con los datos del formulario
+func (s *Server) handleAdminCreateDish(c *fiber.Ctx) error {
+	request, form := parseDishForm(c)
+	if len(form.Errors) > 0 {
+		c.Status(fiber.StatusUnprocessableEntity)
+		return render(c, templates.AdminDishForm(form))
+	}
+
+	dish, err := s.apiClient.CreateDish(c.UserContext(), sessionToken(c), request)
+	if err != nil {
+		return s.dishFormError(c, form, err, "No se pudo crear el plato")
+	}
+
+	return c.Redirect(adminWeekURL(dish.AvailableOn), fiber.StatusSeeOther)
+}
+
+// handleAdminEditDish renderiza el formulario de edición de un plato
+func (s *Server) handleAdminEditDish(c *fiber.Ctx) error {
+	dish, err := s.apiClient.GetDish(c.UserContext(), sessionToken(c), c.Params("id"))
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		if isNotFound(err) {
+			return fiber.NewError(fiber.StatusNotFound, "Plato no encontrado")
+		}
+		log.Printf("Error al obtener el plato %s: %v", c.Params("id"), err)
+		return fiber.NewError(fiber.StatusBadGateway, "No se pudo obtener el plato")
+	}
+
+	return render(c, templates.AdminDishForm(dishForm(dish)))
+}
+
+// handleAdminUpdateDish actualiza un plato con los datos del formulario. Los
+// formularios HTML sólo envían GET y POST, por lo que se usa POST en lugar de
+// PUT.
+func (s *Server) handleAdminUpdateDish(c *fiber.Ctx) error {
+	request, form := parseDishForm(c)
+	form.ID = c.Params("id")
+	if len(form.Errors) > 0 {
+		c.Status(fiber.StatusUnprocessableEntity)
+		return render(c, templates.AdminDishForm(form))
+	}
+
+	dish, err := s.apiClient.UpdateDish(c.UserContext(), sessionToken(c), form.ID, request)
+	if err != nil {
+		return s.dishFormError(c, form, err, "No se pudo actualizar el plato")
+	}
+
+	return c.Redirect(adminWeekURL(dish.AvailableOn), fiber.StatusSeeOther)
+}
+
+// handleAdminDeleteDish elimina un plato y vuelve a la semana del formulario
+func (s *Server) handleAdminDeleteDish(c *fiber.Ctx) error {
+	start := weekStart(parseDateOr(c.FormValue("week"), today()))
+
+	if err := s.apiClient.DeleteDish(c.UserContext(), sessionToken(c), c.Params("id")); err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		c.Status(fiber.StatusConflict)
+		return s.renderAdminWeek(c, start, "", apiErrorMessage(err, "No se pudo eliminar el plato"))
+	}
+
+	return c.Redirect(adminWeekURL(start), fiber.StatusSeeOther)
+}
+
+// handleAdminPreview muestra el menú de ?date= tal como lo verán los clientes
+func (s *Server) handleAdminPreview(c *fiber.Ctx) error {
+	date := today()
+	errorMessage := ""
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			errorMessage = "Fecha inválida"
+		} else {
+			date = parsed
+		}
+	}
+
+	page, err := s.loadMenuPage(c, date, errorMessage)
+	if err != nil {
+		return s.unauthenticated(c)
+	}
+	page.Preview = true
+	return render(c, templates.Menu(page))
+}
+
+func (s *Server) renderAdminWeek(c *fiber.Ctx, start time.Time, message, errorMessage string) error {
+	week := templates.AdminWeek{
+		Start:        start,
+		Days:         make([]templates.AdminDay, 7),
+		Message:      message,
+		ErrorMessage: errorMessage,
+	}
+	for i := range week.Days {
+		week.Days[i].Date = start.AddDate(0, 0, i)
+	}
+
+	dishes, err := s.apiClient.ListDishes(c.UserContext(), sessionToken(c), start, start.AddDate(0, 0, 6))
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		week.ErrorMessage = apiErrorMessage(err, "No se pudieron obtener los platos")
+	}
+
+	for _, dish := range dishes {
+		i := int(dish.AvailableOn.Sub(start).Hours() / 24)
+		if i < 0 || i >= len(week.Days) {
+			continue
+		}
+		week.Days[i].Dishes = append(week.Days[i].Dishes, templates.AdminDish{
+			ID:              dish.ID,
+			Name:            dish.Name,
+			Price:           dish.Price,
+			PrepTimeMinutes: dish.PrepTimeMinutes,
+		})
+	}
+
+	return render(c, templates.AdminDishes(week))
+}
+
+// dishFormError vuelve a mostrar el formulario con el error de la API
+func (s *Server) dishFormError(c *fiber.Ctx, form templates.DishForm, err error, message string) error {
+	if IsUnauthorized(err) {
+		return s.unauthenticated(c)
+	}
+	if isNotFound(err) {
+		return fiber.NewError(fiber.StatusNotFound, "Plato no encontrado")
+	}
+	form.ErrorMessage = apiErrorMessage(err, message)
+	c.Status(fiber.StatusUnprocessableEntity)
+	return render(c, templates.AdminDishForm(form))
+}
+
+// parseDishForm lee y valida el formulario de un plato. El formulario
+// retornado conserva lo que ingresó el usuario junto con los errores.
+func parseDishForm(c *fiber.Ctx) (DishRequest, templates.DishForm) {
+	form := templates.DishForm{
+		Name:            strings.TrimSpace(c.FormValue("name")),
+		Description:     strings.TrimSpace(c.FormValue("description")),
+		Price:           strings.TrimSpace(c.FormValue("price")),
+		PrepTimeMinutes: strings.TrimSpace(c.FormValue("prep_time_minutes")),
+		AvailableOn:     strings.TrimSpace(c.FormValue("available_on")),
+		Errors:          map[string]string{},
+	}
+	request := DishRequest{
+		Name:        form.Name,
+		Description: form.Description,
+	}
+
+	switch {
+	case form.Name == "":
+		form.Errors["name"] = "El nombre es obligatorio"
+	case len(form.Name) > 255:
+		form.Errors["name"] = "El nombre no puede superar los 255 caracteres"
+	}
+
+	price, err := utils.ParseMoney(strings.Replace(form.Price, ",", ".", 1), utils.DefaultCurrency)
+	switch {
+	case form.Price == "":
+		form.Errors["price"] = "El precio es obligatorio"
+	case errors.Is(err, utils.ErrMoneyPrecision):
+		form.Errors["price"] = fmt.Sprintf("El precio admite hasta %d decimales", utils.CurrencyDigits(utils.DefaultCurrency))
+	case err != nil:
+		form.Errors["price"] = "El precio debe ser un número"
+	case price.Amount <= 0:
+		form.Errors["price"] = "El precio debe ser mayor que cero"
+	}
+	request.Price = price
+
+	prepTime, err := strconv.Atoi(form.PrepTimeMinutes)
+	switch {
+	case form.PrepTimeMinutes == "":
+		form.Errors["prep_time_minutes"] = "El tiempo de preparación es obligatorio"
+	case err != nil:
+		form.Errors["prep_time_minutes"] = "El tiempo de preparación debe ser un número entero de minutos"
+	case prepTime <= 0:
+		form.Errors["prep_time_minutes"] = "El tiempo de preparación debe ser mayor que cero"
+	}
+	request.PrepTimeMinutes = prepTime
+
+	availableOn, err := time.Parse("2006-01-02", form.AvailableOn)
+	if err != nil {
+		form.Errors["available_on"] = "Ingresa una fecha válida"
+	}
+	request.AvailableOn = availableOn
+
+	return request, form
+}
+
+func dishForm(dish Dish) templates.DishForm {
+	return templates.DishForm{
+		ID:              dish.ID,
+		Name:            dish.Name,
+		Description:     dish.Description,
+		Price:           dish.Price.String(),
+		PrepTimeMinutes: strconv.Itoa(dish.PrepTimeMinutes),
+		AvailableOn:     dish.AvailableOn.Format("2006-01-02"),
+	}
+}
+
+// weekStart retorna el lunes de la semana de date
+func weekStart(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+func adminWeekURL(date time.Time) string {
+	return "/admin/dishes?week=" + weekStart(date).Format("2006-01-02")
+}
+
+// parseDateOr parsea una fecha YYYY-MM-DD o retorna fallback si no es válida
+func parseDateOr(value string, fallback time.Time) time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return fallback
+	}
+	return date
+}
+
+// isNotFound indica si la API respondió 404
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == fiber.StatusNotFound
+}
@@ -0,0 +1,228 @@
+package web
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/web/templates"
+)
+
+// menuDays es la cantidad de días que se ofrecen en el selector del menú
+const menuDays = 7
+
+// handleMenu renderiza el menú del día indicado en ?date= (YYYY-MM-DD) o el
+// de hoy
+func (s *Server) handleMenu(c *fiber.Ctx) error {
+	date := today()
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.Status(fiber.StatusBadRequest)
+			return render(c, templates.Menu(menuPage(date, nil, "Fecha inválida")))
+		}
+		date = parsed
+	}
+	return s.renderMenu(c, date, "")
+}
+
+// handlePlaceOrder crea una orden con las líneas del formulario del menú y
+// redirige a su detalle. Los campos dish_id, quantity y notes se repiten una
+// vez por plato; los platos con cantidad 0 se ignoran.
+func (s *Server) handlePlaceOrder(c *fiber.Ctx) error {
+	date, err := time.Parse("2006-01-02", c.FormValue("date"))
+	if err != nil {
+		date = today()
+	}
+
+	items, err := orderItemsFromForm(c)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest)
+		return s.renderMenu(c, date, err.Error())
+	}
+
+	key := c.FormValue("idempotency_key")
+	if key == "" {
+		key = uuid.NewString()
+	}
+
+	order, err := s.apiClient.PlaceOrder(c.UserContext(), sessionToken(c), key, items)
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		c.Status(fiber.StatusUnprocessableEntity)
+		return s.renderMenu(c, date, apiErrorMessage(err, "No se pudo crear la orden"))
+	}
+
+	return c.Redirect("/my/orders/"+order.ID, fiber.StatusSeeOther)
+}
+
+// handleMyOrders renderiza el historial de órdenes del usuario de la sesión
+func (s *Server) handleMyOrders(c *fiber.Ctx) error {
+	orders, err := s.apiClient.GetMyOrders(c.UserContext(), sessionToken(c))
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		return render(c, templates.MyOrders(nil, apiErrorMessage(err, "No se pudieron obtener tus órdenes")))
+	}
+
+	result := make([]templates.CustomerOrder, len(orders))
+	for i, order := range orders {
+		result[i] = customerOrder(order)
+	}
+	return render(c, templates.MyOrders(result, ""))
+}
+
+// handleMyOrder renderiza el detalle de una orden del usuario de la sesión
+func (s *Server) handleMyOrder(c *fiber.Ctx) error {
+	order, err := s.apiClient.GetOrder(c.UserContext(), sessionToken(c), c.Params("id"))
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == fiber.StatusNotFound || apiErr.StatusCode == fiber.StatusBadRequest) {
+			return fiber.NewError(fiber.StatusNotFound, "Orden no encontrada")
+		}
+		log.Printf("Error al obtener la orden %s: %v", c.Params("id"), err)
+		return fiber.NewError(fiber.StatusBadGateway, "No se pudo obtener la orden")
+	}
+
+	// El reader muestra las órdenes de otros usuarios al personal de cocina;
+	// esta página es sólo para las propias
+	if order.UserID != sessionUserID(c) {
+		return fiber.NewError(fiber.StatusNotFound, "Orden no encontrada")
+	}
+
+	return render(c, templates.OrderDetail(customerOrder(order)))
+}
+
+func (s *Server) renderMenu(c *fiber.Ctx, date time.Time, errorMessage string) error {
+	page, err := s.loadMenuPage(c, date, errorMessage)
+	if err != nil {
+		return s.unauthenticated(c)
+	}
+	return render(c, templates.Menu(page))
+}
+
+// loadMenuPage obtiene el menú de una fecha. Los errores de la API se
+// muestran en la página; sólo retorna error si la sesión ya no es válida.
+func (s *Server) loadMenuPage(c *fiber.Ctx, date time.Time, errorMessage string) (templates.MenuPage, error) {
+	menu, err := s.apiClient.GetMenu(c.UserContext(), sessionToken(c), date)
+	if err != nil {
+		if IsUnauthorized(err) {
+			return templates.MenuPage{}, err
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != fiber.StatusNotFound {
+			errorMessage = apiErrorMessage(err, "No se pudo obtener el menú")
+		}
+	}
+	return menuPage(date, menu, errorMessage), nil
+}
+
+// menuPage arma la página del menú con una Idempotency-Key nueva para el
+// formulario
+func menuPage(date time.Time, menu []MenuItem, errorMessage string) templates.MenuPage {
+	start := today()
+	days := make([]time.Time, menuDays)
+	for i := range days {
+		days[i] = start.AddDate(0, 0, i)
+	}
+
+	dishes := make([]templates.MenuDish, len(menu))
+	for i, item := range menu {
+		dishes[i] = templates.MenuDish{
+			ID:              item.ID,
+			Name:            item.Name,
+			Description:     item.Description,
+			Price:           item.Price,
+			PrepTimeMinutes: item.PrepTimeMinutes,
+		}
+	}
+
+	return templates.MenuPage{
+		Date:           date,
+		Days:           days,
+		Dishes:         dishes,
+		IdempotencyKey: uuid.NewString(),
+		ErrorMessage:   errorMessage,
+	}
+}
+
+// orderItemsFromForm lee las líneas de la orden del formulario del menú
+func orderItemsFromForm(c *fiber.Ctx) ([]OrderItemRequest, error) {
+	args := c.Request().PostArgs()
+	dishIDs := args.PeekMulti("dish_id")
+	quantities := args.PeekMulti("quantity")
+	notes := args.PeekMulti("notes")
+	if len(quantities) != len(dishIDs) || len(notes) != len(dishIDs) {
+		return nil, errors.New("Formulario inválido")
+	}
+
+	var items []OrderItemRequest
+	for i, dishID := range dishIDs {
+		quantity, err := strconv.Atoi(strings.TrimSpace(string(quantities[i])))
+		if err != nil || quantity < 0 {
+			return nil, errors.New("Cantidad inválida")
+		}
+		if quantity == 0 {
+			continue
+		}
+		items = append(items, OrderItemRequest{
+			DishID:   string(dishID),
+			Quantity: quantity,
+			Notes:    strings.TrimSpace(string(notes[i])),
+		})
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("Elige al menos un plato")
+	}
+	return items, nil
+}
+
+func customerOrder(order Order) templates.CustomerOrder {
+	items := make([]templates.CustomerOrderItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = templates.CustomerOrderItem{
+			DishName:  item.DishName,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Subtotal:  item.Subtotal,
+			Notes:     item.Notes,
+		}
+	}
+
+	return templates.CustomerOrder{
+		ID:        order.ID,
+		Status:    order.Status,
+		Items:     items,
+		Total:     order.Total,
+		CreatedAt: order.CreatedAt,
+		UpdatedAt: order.UpdatedAt,
+	}
+}
+
+// apiErrorMessage arma el mensaje que se muestra al usuario cuando falla una
+// llamada a la API. Los errores 4xx incluyen el motivo; el resto se registra.
+func apiErrorMessage(err error, message string) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Message != "" && apiErr.StatusCode < fiber.StatusInternalServerError {
+		return message + ": " + apiErr.Message
+	}
+	log.Printf("%s: %v", message, err)
+	return message
+}
+
+// today retorna la fecha de hoy sin hora
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodrwan/themenu/internal/cqrs/commands"
+	"github.com/rodrwan/themenu/internal/web/templates"
+)
+
+// kitchenStatuses son las columnas del tablero de cocina, en orden
+var kitchenStatuses = []string{
+	commands.OrderStatusReceived,
+	commands.OrderStatusConfirmed,
+	commands.OrderStatusPreparing,
+}
+
+// handleKitchen renderiza la pantalla de cocina completa
+func (s *Server) handleKitchen(c *fiber.Ctx) error {
+	columns, err := s.kitchenColumns(c)
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		log.Printf("Error al obtener la cola de cocina: %v", err)
+		return render(c, templates.Kitchen(nil, "No se pudo obtener la cola de cocina"))
+	}
+	return render(c, templates.Kitchen(columns, ""))
+}
+
+// handleKitchenBoard renderiza sólo el tablero, para refrescarlo con htmx
+func (s *Server) handleKitchenBoard(c *fiber.Ctx) error {
+	return s.renderKitchenBoard(c, "")
+}
+
+// handleKitchenUpdateStatus mueve una orden al estado indicado en el
+// formulario. Con htmx responde el tablero actualizado; sin JavaScript
+// redirige a la pantalla de cocina.
+func (s *Server) handleKitchenUpdateStatus(c *fiber.Ctx) error {
+	orderID := c.Params("id")
+	status := c.FormValue("status")
+
+	errorMessage := ""
+	err := s.apiClient.UpdateOrderStatus(c.UserContext(), sessionToken(c), orderID, status)
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		errorMessage = apiErrorMessage(err, "No se pudo actualizar la orden")
+	}
+
+	if isHTMX(c) {
+		return s.renderKitchenBoard(c, errorMessage)
+	}
+	if errorMessage != "" {
+		columns, err := s.kitchenColumns(c)
+		if err != nil {
+			log.Printf("Error al obtener la cola de cocina: %v", err)
+		}
+		c.Status(fiber.StatusConflict)
+		return render(c, templates.Kitchen(columns, errorMessage))
+	}
+	return c.Redirect("/kitchen", fiber.StatusSeeOther)
+}
+
+func (s *Server) renderKitchenBoard(c *fiber.Ctx, errorMessage string) error {
+	columns, err := s.kitchenColumns(c)
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		log.Printf("Error al obtener la cola de cocina: %v", err)
+		errorMessage = "No se pudo obtener la cola de cocina"
+	}
+	return render(c, templates.KitchenBoard(columns, errorMessage))
+}
+
+// kitchenColumns obtiene la cola de cocina del reader y la agrupa por estado
+func (s *Server) kitchenColumns(c *fiber.Ctx) ([]templates.KitchenColumn, error) {
+	orders, err := s.apiClient.GetOrders(c.UserContext(), sessionToken(c))
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]templates.KitchenColumn, len(kitchenStatuses))
+	index := make(map[string]int, len(kitchenStatuses))
+	for i, status := range kitchenStatuses {
+		columns[i] = templates.KitchenColumn{Status: status}
+		index[status] = i
+	}
+
+	for _, order := range orders {
+		i, ok := index[order.Status]
+		if !ok {
+			continue
+		}
+		columns[i].Tickets = append(columns[i].Tickets, kitchenTicket(order))
+	}
+
+	return columns, nil
+}
+
+func kitchenTicket(order Order) templates.KitchenTicket {
+	items := make([]templates.KitchenItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = templates.KitchenItem{
+			DishName: item.DishName,
+			Quantity: item.Quantity,
+			Notes:    item.Notes,
+		}
+	}
+
+	return templates.KitchenTicket{
+		ID:              order.ID,
+		Status:          order.Status,
+		Items:           items,
+		CreatedAt:       order.CreatedAt,
+		DueAt:           order.DueAt,
+		PrepTimeMinutes: order.PrepTimeMinutes,
+		AgeSeconds:      order.AgeSeconds,
+		Overdue:         order.Overdue,
+		Next:            commands.OrderTransitions.Next(order.Status, nil),
+	}
+}
+
+// isHTMX indica si el request lo hizo htmx
+func isHTMX(c *fiber.Ctx) bool {
+	return c.Get("HX-Request") == "true"
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rodrwan/themenu/internal/web/templates"
+)
+
+// handleMyNotifications renderiza las notificaciones del usuario de la sesión.
+// ?cursor= muestra la página siguiente a la del cursor.
+func (s *Server) handleMyNotifications(c *fiber.Ctx) error {
+	return s.renderNotifications(c, c.Query("cursor"), "")
+}
+
+// handleMarkNotificationRead marca una notificación como leída y vuelve a la
+// página de notificaciones en la que estaba el usuario
+func (s *Server) handleMarkNotificationRead(c *fiber.Ctx) error {
+	err := s.apiClient.MarkNotificationRead(c.UserContext(), sessionToken(c), c.Params("id"))
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		c.Status(fiber.StatusUnprocessableEntity)
+		return s.renderNotifications(c, c.FormValue("cursor"), apiErrorMessage(err, "No se pudo marcar la notificación como leída"))
+	}
+	return c.Redirect(notificationsURL(c.FormValue("cursor")), fiber.StatusSeeOther)
+}
+
+// handleMarkAllNotificationsRead marca como leídas todas las notificaciones
+// del usuario de la sesión
+func (s *Server) handleMarkAllNotificationsRead(c *fiber.Ctx) error {
+	if err := s.apiClient.MarkAllNotificationsRead(c.UserContext(), sessionToken(c)); err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		c.Status(fiber.StatusUnprocessableEntity)
+		return s.renderNotifications(c, "", apiErrorMessage(err, "No se pudieron marcar las notificaciones como leídas"))
+	}
+	return c.Redirect("/my/notifications", fiber.StatusSeeOther)
+}
+
+func (s *Server) renderNotifications(c *fiber.Ctx, cursor, errorMessage string) error {
+	page := templates.NotificationsPage{
+		Cursor:       cursor,
+		ErrorMessage: errorMessage,
+	}
+
+	result, err := s.apiClient.GetNotifications(c.UserContext(), sessionToken(c), cursor)
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		if page.ErrorMessage == "" {
+			page.ErrorMessage = apiErrorMessage(err, "No se pudieron obtener tus notificaciones")
+		}
+		return render(c, templates.MyNotifications(page))
+	}
+
+	page.Unread = result.Unread
+	page.NextCursor = result.NextCursor
+	page.Notifications = make([]templates.NotificationItem, len(result.Notifications))
+	for i, notification := range result.Notifications {
+		page.Notifications[i] = templates.NotificationItem{
+			ID:      notification.ID,
+			OrderID: notification.OrderID,
+			Message: notification.Message,
+			SentAt:  notification.SentAt,
+			Read:    notification.ReadAt != nil,
+		}
+	}
+	return render(c, templates.MyNotifications(page))
+}
+
+// notificationsURL es la ruta de la página de notificaciones de un cursor
+func notificationsURL(cursor string) string {
+	if cursor == "" {
+		return "/my/notifications"
+	}
+	return "/my/notifications?cursor=" + url.QueryEscape(cursor)
+}
@@ -0,0 +1,67 @@
+package web
+
+import (
+	"sync"
+
+	"github.com/rodrwan/themenu/internal/cqrs"
+)
+
+// ReplayBufferSize define cuántos eventos recientes guarda cada instancia web
+// para reenviarlos a los clientes SSE que se reconectan
+const ReplayBufferSize = 1000
+
+// replayBuffer es un buffer circular con los últimos eventos recibidos
+type replayBuffer struct {
+	mu     sync.RWMutex
+	events []cqrs.Event
+	start  int
+	count  int
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{
+		events: make([]cqrs.Event, size),
+	}
+}
+
+// add agrega un evento, descartando el más antiguo si el buffer está lleno
+func (b *replayBuffer) add(event cqrs.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	size := len(b.events)
+	if b.count < size {
+		b.events[(b.start+b.count)%size] = event
+		b.count++
+		return
+	}
+	b.events[b.start] = event
+	b.start = (b.start + 1) % size
+}
+
+// since retorna, en orden, los eventos recibidos después del evento lastID.
+// found es false si lastID ya no está en el buffer (o nunca estuvo, por
+// ejemplo tras reiniciar el servicio); en ese caso no se retorna ningún
+// evento, ya que no se sabe cuáles se perdieron.
+func (b *replayBuffer) since(lastID string) (events []cqrs.Event, found bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	size := len(b.events)
+	from := -1
+	for i := b.count - 1; i >= 0; i-- {
+		if b.events[(b.start+i)%size].ID == lastID {
+			from = i + 1
+			break
+		}
+	}
+	if from < 0 {
+		return nil, false
+	}
+
+	events = make([]cqrs.Event, 0, b.count-from)
+	for i := from; i < b.count; i++ {
+		events = append(events, b.events[(b.start+i)%size])
+	}
+	return events, true
+}
@@ -3,13 +3,19 @@ package web
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/a-h/templ"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/rodrwan/themenu/internal/auth"
 	"github.com/rodrwan/themenu/internal/cqrs"
 	"github.com/rodrwan/themenu/internal/web/templates"
 )
@@ -18,14 +24,40 @@ type Server struct {
 	app       *fiber.App
 	eventBus  *cqrs.EventBus
 	apiClient APIClient
+	replay    *replayBuffer
+	tokens    *auth.Manager
+	config    Config
 }
 
+// Config agrupa la configuración del servidor web
+type Config struct {
+	// SecureCookies marca la cookie de sesión como Secure, para servir la
+	// interfaz sólo por HTTPS
+	SecureCookies bool
+}
+
+// APIClient llama a la API con el token de la sesión del usuario
 type APIClient interface {
-	GetOrders() ([]Order, error)
-	UpdateOrderStatus(orderID, status string) error
+	Login(ctx context.Context, email, password string) (Session, error)
+	GetPermissions(ctx context.Context, token string) ([]string, error)
+	ListDishes(ctx context.Context, token string, from, to time.Time) ([]Dish, error)
+	GetDish(ctx context.Context, token, dishID string) (Dish, error)
+	CreateDish(ctx context.Context, token string, dish DishRequest) (Dish, error)
+	UpdateDish(ctx context.Context, token, dishID string, dish DishRequest) (Dish, error)
+	DeleteDish(ctx context.Context, token, dishID string) error
+	CloneWeek(ctx context.Context, token string, weekStart time.Time) (int, error)
+	GetMenu(ctx context.Context, token string, date time.Time) ([]MenuItem, error)
+	PlaceOrder(ctx context.Context, token, idempotencyKey string, items []OrderItemRequest) (Order, error)
+	GetMyOrders(ctx context.Context, token string) ([]Order, error)
+	GetOrder(ctx context.Context, token, orderID string) (Order, error)
+	GetOrders(ctx context.Context, token string) ([]Order, error)
+	UpdateOrderStatus(ctx context.Context, token, orderID, status string) error
+	GetNotifications(ctx context.Context, token, cursor string) (NotificationPage, error)
+	MarkNotificationRead(ctx context.Context, token, notificationID string) error
+	MarkAllNotificationsRead(ctx context.Context, token string) error
 }
 
-func NewServer(eventBus *cqrs.EventBus, apiClient APIClient) *Server {
+func NewServer(eventBus *cqrs.EventBus, apiClient APIClient, tokens *auth.Manager, config Config) *Server {
 	app := fiber.New()
 
 	app.Use(cors.New())
@@ -37,13 +69,49 @@ func NewServer(eventBus *cqrs.EventBus, apiClient APIClient) *Server {
 		app:       app,
 		eventBus:  eventBus,
 		apiClient: apiClient,
+		replay:    newReplayBuffer(ReplayBufferSize),
+		tokens:    tokens,
+		config:    config,
 	}
 
-	// Rutas
-	app.Get("/", server.handleDashboard)
-	app.Get("/events", server.handleSSE)
-	app.Get("/orders", server.handleOrders)
-	app.Patch("/orders/:id/status", server.handleUpdateOrderStatus)
+	// Guardar los eventos recientes para los clientes que se reconectan. Se
+	// registra como handler para que el buffer nunca se desconecte por lento
+	eventBus.SubscribeHandler(cqrs.EventFilter{}, func(event cqrs.Event) error {
+		server.replay.add(event)
+		return nil
+	})
+
+	// Rutas públicas
+	app.Get("/login", server.handleLoginPage)
+	app.Post("/login", server.handleLogin)
+	app.Post("/logout", server.handleLogout)
+
+	// Rutas que requieren sesión
+	app.Get("/", server.requireSession, server.handleDashboard)
+	app.Get("/events", server.requireSession, server.handleSSE)
+	app.Get("/menu", server.requireSession, server.handleMenu)
+	app.Post("/orders", server.requireSession, server.handlePlaceOrder)
+	app.Get("/my/orders", server.requireSession, server.handleMyOrders)
+	app.Get("/my/orders/:id", server.requireSession, server.handleMyOrder)
+	app.Get("/my/events", server.requireSession, server.handleMyEvents)
+	app.Get("/my/notifications", server.requireSession, server.handleMyNotifications)
+	app.Post("/my/notifications/read", server.requireSession, server.handleMarkAllNotificationsRead)
+	app.Post("/my/notifications/:id/read", server.requireSession, server.handleMarkNotificationRead)
+	app.Get("/my/notifications/events", server.requireSession, server.handleMyNotificationEvents)
+	app.Get("/kitchen", server.requireSession, server.handleKitchen)
+	app.Get("/kitchen/board", server.requireSession, server.handleKitchenBoard)
+	app.Post("/kitchen/orders/:id/status", server.requireSession, server.handleKitchenUpdateStatus)
+
+	// Consola de administración de platos
+	admin := app.Group("/admin", server.requireSession, server.requirePermission(auth.PermManageDishes))
+	admin.Get("/dishes", server.handleAdminDishes)
+	admin.Post("/dishes", server.handleAdminCreateDish)
+	admin.Get("/dishes/new", server.handleAdminNewDish)
+	admin.Post("/dishes/clone-week", server.handleAdminCloneWeek)
+	admin.Get("/dishes/:id/edit", server.handleAdminEditDish)
+	admin.Post("/dishes/:id", server.handleAdminUpdateDish)
+	admin.Post("/dishes/:id/delete", server.handleAdminDeleteDish)
+	admin.Get("/preview", server.handleAdminPreview)
 
 	return server
 }
@@ -52,20 +120,101 @@ func (s *Server) Start(addr string) error {
 	return s.app.Listen(addr)
 }
 
-func (s *Server) handleDashboard(c *fiber.Ctx) error {
-	// Por ahora, enviamos una lista vacía de eventos
-	component := templates.Dashboard([]cqrs.Event{})
-
+// render responde con un componente templ
+func render(c *fiber.Ctx, component templ.Component) error {
 	var buf bytes.Buffer
-	if err := component.Render(c.Context(), &buf); err != nil {
+	if err := component.Render(c.UserContext(), &buf); err != nil {
 		return err
 	}
 	c.Type("html")
 	return c.SendString(buf.String())
 }
 
+func (s *Server) handleDashboard(c *fiber.Ctx) error {
+	// Por ahora, enviamos una lista vacía de eventos
+	return render(c, templates.Dashboard([]cqrs.Event{}))
+}
+
+func (s *Server) handleLoginPage(c *fiber.Ctx) error {
+	return render(c, templates.Login(safeRedirect(c.Query("next")), "", ""))
+}
+
+// handleLogin obtiene un token del writer con el email y la contraseña
+// indicados y lo guarda en la cookie de sesión
+func (s *Server) handleLogin(c *fiber.Ctx) error {
+	email := strings.TrimSpace(c.FormValue("email"))
+	password := c.FormValue("password")
+	next := safeRedirect(c.FormValue("next"))
+
+	if email == "" || password == "" {
+		c.Status(fiber.StatusBadRequest)
+		return render(c, templates.Login(next, email, "Ingresa tu email y tu contraseña"))
+	}
+
+	session, err := s.apiClient.Login(c.UserContext(), email, password)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < fiber.StatusInternalServerError {
+			c.Status(fiber.StatusUnauthorized)
+			return render(c, templates.Login(next, email, "Email o contraseña incorrectos"))
+		}
+		log.Printf("Error al iniciar sesión: %v", err)
+		c.Status(fiber.StatusBadGateway)
+		return render(c, templates.Login(next, email, "No se pudo iniciar sesión, intenta nuevamente"))
+	}
+
+	s.setSession(c, session)
+	return c.Redirect(next, fiber.StatusSeeOther)
+}
+
+func (s *Server) handleLogout(c *fiber.Ctx) error {
+	s.clearSession(c)
+	return c.Redirect("/login", fiber.StatusSeeOther)
+}
+
 func (s *Server) handleSSE(c *fiber.Ctx) error {
-	log.Printf("[SSE] Nueva conexión establecida")
+	// Filtros opcionales: ?types=Order*,Dish*&status=received,preparing
+	filter := cqrs.ParseEventFilter(c.Query("types"), c.Query("status"))
+
+	// Sólo quien atiende las órdenes ve los eventos de todos los usuarios; el
+	// resto recibe únicamente los eventos de su propio usuario
+	permissions, err := s.apiClient.GetPermissions(c.UserContext(), sessionToken(c))
+	if err != nil {
+		if IsUnauthorized(err) {
+			return s.unauthenticated(c)
+		}
+		log.Printf("Error al obtener los permisos del usuario: %v", err)
+		return fiber.NewError(fiber.StatusBadGateway, "No se pudieron verificar los permisos")
+	}
+	if !slices.Contains(permissions, auth.PermUpdateOrderStatus) {
+		filter.UserIDs = []string{sessionUserID(c)}
+	}
+	return s.streamEvents(c, filter)
+}
+
+// handleMyEvents transmite sólo los eventos de órdenes del usuario de la sesión
+func (s *Server) handleMyEvents(c *fiber.Ctx) error {
+	return s.streamEvents(c, cqrs.EventFilter{
+		Types:   []string{"Order*"},
+		UserIDs: []string{sessionUserID(c)},
+	})
+}
+
+// handleMyNotificationEvents transmite las notificaciones nuevas y leídas del
+// usuario de la sesión
+func (s *Server) handleMyNotificationEvents(c *fiber.Ctx) error {
+	return s.streamEvents(c, cqrs.EventFilter{
+		Types:   []string{cqrs.EventNotificationSent, cqrs.EventNotificationRead},
+		UserIDs: []string{sessionUserID(c)},
+	})
+}
+
+// streamEvents transmite por SSE los eventos que cumplen el filtro
+func (s *Server) streamEvents(c *fiber.Ctx, filter cqrs.EventFilter) error {
+	// El navegador envía Last-Event-ID al reconectarse automáticamente; los
+	// clientes que recrean el EventSource pueden usar ?lastEventId=
+	lastEventID := c.Get("Last-Event-ID", c.Query("lastEventId"))
+	log.Printf("[SSE] Nueva conexión establecida (types=%v, status=%v, users=%v, last_event_id=%q)", filter.Types, filter.Statuses, filter.UserIDs, lastEventID)
 
 	// Configurar headers SSE
 	c.Set("Content-Type", "text/event-stream")
@@ -73,22 +222,60 @@ func (s *Server) handleSSE(c *fiber.Ctx) error {
 	c.Set("Connection", "keep-alive")
 	c.Set("Transfer-Encoding", "chunked")
 
-	// Crear un canal para los eventos
-	eventChan := s.eventBus.Subscribe("*")
-	ticker := time.NewTicker(10 * time.Second)
+	// Suscribirse antes de leer el buffer para no perder eventos entre el
+	// replay y el streaming en vivo
+	eventChan := s.eventBus.SubscribeFilter(filter)
 
-	// Limpiar recursos cuando se cierre la conexión
-	defer func() {
-		ticker.Stop()
-		log.Printf("[SSE] Conexión cerrada, recursos liberados")
-	}()
+	var missed []cqrs.Event
+	reset := false
+	if lastEventID != "" {
+		var found bool
+		missed, found = s.replay.since(lastEventID)
+		reset = !found
+	}
 
-	// Configurar el writer para streaming
+	// Configurar el writer para streaming. El writer se ejecuta después de que
+	// el handler retorna, por lo que los recursos se liberan dentro de él.
 	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ticker := time.NewTicker(10 * time.Second)
+		defer func() {
+			ticker.Stop()
+			s.eventBus.Unsubscribe(eventChan)
+			log.Printf("[SSE] Conexión cerrada, recursos liberados")
+		}()
+
 		// Enviar un mensaje inicial
 		log.Printf("[SSE] Enviando mensaje inicial")
 		w.WriteString("data: connected\n\n")
-		w.Flush()
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		// Si el último evento recibido ya no está en el buffer no se sabe qué
+		// se perdió: se avisa al cliente con "reset" para que recargue su
+		// estado en vez de reenviarle el buffer completo
+		if reset {
+			log.Printf("[SSE] %s no está en el buffer, enviando reset", lastEventID)
+			w.WriteString("data: reset\n\n")
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+
+		// Reenviar los eventos perdidos durante la desconexión
+		replayed := make(map[string]struct{}, len(missed))
+		for _, event := range missed {
+			if !filter.Matches(event) {
+				continue
+			}
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+			replayed[event.ID] = struct{}{}
+		}
+		if len(replayed) > 0 {
+			log.Printf("[SSE] Reenviados %d eventos desde %s", len(replayed), lastEventID)
+		}
 
 		for {
 			select {
@@ -98,20 +285,23 @@ func (s *Server) handleSSE(c *fiber.Ctx) error {
 					return
 				}
 
-				log.Printf("[SSE] Evento recibido: %s", event.Type)
-				data, err := json.Marshal(event)
-				if err != nil {
-					log.Printf("[SSE] Error al serializar evento: %v", err)
+				// Evitar duplicar eventos que ya llegaron en el replay
+				if _, ok := replayed[event.ID]; ok {
+					delete(replayed, event.ID)
 					continue
 				}
 
-				w.WriteString(fmt.Sprintf("data: %s\n\n", string(data)))
-				w.Flush()
+				log.Printf("[SSE] Evento recibido: %s", event.Type)
+				if err := writeSSEEvent(w, event); err != nil {
+					return
+				}
 
 			case <-ticker.C:
 				log.Printf("[SSE] Enviando ping")
 				w.WriteString("data: ping\n\n")
-				w.Flush()
+				if err := w.Flush(); err != nil {
+					return
+				}
 			}
 		}
 	})
@@ -119,34 +309,15 @@ func (s *Server) handleSSE(c *fiber.Ctx) error {
 	return nil
 }
 
-func (s *Server) handleOrders(c *fiber.Ctx) error {
-	// Get order from api service
-	orders, err := s.apiClient.GetOrders()
-	if err != nil {
-		log.Printf("Error getting orders: %v", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to get orders"})
-	}
-	return c.JSON(orders)
-}
-
-func (s *Server) handleUpdateOrderStatus(c *fiber.Ctx) error {
-	orderID := c.Params("id")
-	var body struct {
-		Status string `json:"status"`
-	}
-	if err := c.BodyParser(&body); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
-	}
-
-	// Update order status in api service
-	err := s.apiClient.UpdateOrderStatus(orderID, body.Status)
+// writeSSEEvent escribe un evento como frame SSE con su id y tipo, de modo que
+// el navegador pueda reconectarse enviando Last-Event-ID
+func writeSSEEvent(w *bufio.Writer, event cqrs.Event) error {
+	data, err := json.Marshal(event)
 	if err != nil {
-		log.Printf("Error updating order status: %v", err)
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to update order status",
-			"message": "Error updating order status",
-		})
+		log.Printf("[SSE] Error al serializar evento: %v", err)
+		return nil
 	}
 
-	return c.JSON(fiber.Map{"message": fmt.Sprintf("Order %s updated to %s", orderID, body.Status)})
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return w.Flush()
 }
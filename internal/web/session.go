@@ -0,0 +1,125 @@
+package web
+
+import (
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionCookieName es la cookie donde se guarda el token del usuario
+const SessionCookieName = "themenu_session"
+
+// Claves de c.Locals con el token y el ID de usuario de la sesión actual
+const (
+	sessionTokenKey  = "session_token"
+	sessionUserIDKey = "session_user_id"
+)
+
+// setSession guarda el token en una cookie HttpOnly que expira junto con él
+func (s *Server) setSession(c *fiber.Ctx, session Session) {
+	c.Cookie(&fiber.Cookie{
+		Name:     SessionCookieName,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		HTTPOnly: true,
+		Secure:   s.config.SecureCookies,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// clearSession elimina la cookie de sesión
+func (s *Server) clearSession(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		Secure:   s.config.SecureCookies,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// requireSession exige una sesión iniciada con un token válido. El token se
+// verifica aquí, y no sólo en la API, porque el web filtra eventos por el
+// usuario de la sesión. Las páginas redirigen al login; las llamadas desde
+// JavaScript y el stream SSE responden 401.
+func (s *Server) requireSession(c *fiber.Ctx) error {
+	token := c.Cookies(SessionCookieName)
+	if token == "" {
+		return s.unauthenticated(c)
+	}
+
+	claims, err := s.tokens.Verify(token)
+	if err != nil {
+		return s.unauthenticated(c)
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return s.unauthenticated(c)
+	}
+
+	c.Locals(sessionTokenKey, token)
+	c.Locals(sessionUserIDKey, userID.String())
+	return c.Next()
+}
+
+// requirePermission exige que el usuario de la sesión tenga el permiso
+// indicado. Los permisos los resuelve el reader, que es quien conoce los
+// roles; la API vuelve a verificarlos en cada llamada. Debe usarse después de
+// requireSession.
+func (s *Server) requirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		permissions, err := s.apiClient.GetPermissions(c.UserContext(), sessionToken(c))
+		if err != nil {
+			if IsUnauthorized(err) {
+				return s.unauthenticated(c)
+			}
+			log.Printf("Error al obtener los permisos del usuario: %v", err)
+			return fiber.NewError(fiber.StatusBadGateway, "No se pudieron verificar los permisos")
+		}
+		if !slices.Contains(permissions, permission) {
+			return fiber.NewError(fiber.StatusForbidden, "Permiso insuficiente")
+		}
+		return c.Next()
+	}
+}
+
+// unauthenticated responde a un request sin sesión válida
+func (s *Server) unauthenticated(c *fiber.Ctx) error {
+	s.clearSession(c)
+	if isHTMX(c) {
+		// htmx no sigue redirecciones de fragmentos; HX-Redirect recarga la página
+		c.Set("HX-Redirect", "/login")
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	if c.Method() == fiber.MethodGet && strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML) {
+		return c.Redirect("/login?next=" + url.QueryEscape(c.OriginalURL()))
+	}
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Sesión no iniciada"})
+}
+
+// sessionToken retorna el token de la sesión actual
+func sessionToken(c *fiber.Ctx) string {
+	token, _ := c.Locals(sessionTokenKey).(string)
+	return token
+}
+
+// sessionUserID retorna el ID del usuario de la sesión actual
+func sessionUserID(c *fiber.Ctx) string {
+	userID, _ := c.Locals(sessionUserIDKey).(string)
+	return userID
+}
+
+// safeRedirect acepta sólo rutas locales para evitar redirecciones abiertas
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
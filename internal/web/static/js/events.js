@@ -4,6 +4,81 @@ document.addEventListener("DOMContentLoaded", function () {
   let reconnectAttempts = 0;
   const maxReconnectAttempts = 5;
   const reconnectDelay = 3000; // 3 segundos
+  // ID del último evento recibido, para recuperar los eventos perdidos al
+  // reconectar
+  let lastEventId = "";
+
+  // Tipos de eventos publicados por el backend (cqrs.EventTypes), que el
+  // servidor escribe en data-event-types. Cada frame llega con
+  // "event: <tipo>", por lo que el EventSource necesita un listener por tipo.
+  const eventTypes = (eventsContainer.dataset.eventTypes || "")
+    .split(",")
+    .filter(Boolean);
+
+  // createElement crea un elemento con sus clases y, opcionalmente, su texto.
+  // El contenido se asigna con textContent porque el payload incluye datos
+  // ingresados por los usuarios, como nombres de platos y notas.
+  function createElement(tag, className, text) {
+    const element = document.createElement(tag);
+    element.className = className;
+    if (text !== undefined) {
+      element.textContent = text;
+    }
+    return element;
+  }
+
+  function renderEvent(event) {
+    console.log("Evento recibido:", event.data);
+    lastEventId = event.lastEventId || lastEventId;
+
+    try {
+      const eventData = JSON.parse(event.data);
+      const eventElement = createElement("div", "border rounded p-4 bg-gray-50");
+
+      const header = createElement("div", "flex justify-between items-center mb-2");
+      const badges = createElement("div", "flex items-center space-x-2");
+      badges.appendChild(
+        createElement(
+          "span",
+          "px-2 py-1 text-xs rounded-full bg-blue-100 text-blue-800",
+          eventData.type
+        )
+      );
+      badges.appendChild(
+        createElement(
+          "span",
+          "px-2 py-1 text-xs rounded-full bg-green-100 text-green-800",
+          eventData.status
+        )
+      );
+      header.appendChild(badges);
+      header.appendChild(
+        createElement(
+          "span",
+          "text-sm text-gray-500",
+          new Date(eventData.timestamp).toLocaleTimeString()
+        )
+      );
+
+      const body = createElement("div", "mt-2");
+      body.appendChild(
+        createElement("div", "text-xs text-gray-500 mb-1", "ID: " + eventData.id)
+      );
+      body.appendChild(
+        createElement(
+          "pre",
+          "text-sm bg-white p-2 rounded border",
+          eventData.payload
+        )
+      );
+
+      eventElement.appendChild(header);
+      eventElement.appendChild(body);
+      eventsContainer.insertBefore(eventElement, eventsContainer.firstChild);
+    } catch (error) {
+      console.error("Error al procesar el evento:", error);
+    }
+  }
 
   function connectSSE() {
     if (eventSource) {
@@ -11,16 +86,18 @@ document.addEventListener("DOMContentLoaded", function () {
     }
 
     console.log("Conectando a SSE...");
-    eventSource = new EventSource("/events");
+    const url = lastEventId
+      ? `/events?lastEventId=${encodeURIComponent(lastEventId)}`
+      : "/events";
+    eventSource = new EventSource(url);
 
     eventSource.onopen = function () {
       console.log("Conexión SSE establecida");
       reconnectAttempts = 0;
     };
 
+    // Los mensajes sin tipo son de control (ping y confirmación)
     eventSource.onmessage = function (event) {
-      console.log("Evento recibido:", event.data);
-
       if (event.data === "ping") {
         console.log("Ping recibido");
         return;
@@ -28,44 +105,20 @@ document.addEventListener("DOMContentLoaded", function () {
 
       if (event.data === "connected") {
         console.log("Conexión SSE confirmada");
-        return;
       }
 
-      try {
-        const eventData = JSON.parse(event.data);
-        const eventElement = document.createElement("div");
-        eventElement.className = "border rounded p-4 bg-gray-50";
-
-        eventElement.innerHTML = `
-                <div class="flex justify-between items-center mb-2">
-                    <div class="flex items-center space-x-2">
-                        <span class="px-2 py-1 text-xs rounded-full bg-blue-100 text-blue-800">
-                            ${eventData.type}
-                        </span>
-                        <span class="px-2 py-1 text-xs rounded-full bg-green-100 text-green-800">
-                            ${eventData.status}
-                        </span>
-                    </div>
-                    <span class="text-sm text-gray-500">${new Date(
-                      eventData.timestamp
-                    ).toLocaleTimeString()}</span>
-                </div>
-                <div class="mt-2">
-                    <div class="text-xs text-gray-500 mb-1">ID: ${
-                      eventData.id
-                    }</div>
-                    <pre class="text-sm bg-white p-2 rounded border">${
-                      eventData.payload
-                    }</pre>
-                </div>
-            `;
-
-        eventsContainer.insertBefore(eventElement, eventsContainer.firstChild);
-      } catch (error) {
-        console.error("Error al procesar el evento:", error);
+      // El servidor ya no tiene el último evento recibido: los eventos
+      // perdidos no se pueden recuperar y se empieza de nuevo
+      if (event.data === "reset") {
+        console.warn("No se pudieron recuperar los eventos perdidos");
+        eventsContainer.replaceChildren();
       }
     };
 
+    eventTypes.forEach(function (type) {
+      eventSource.addEventListener(type, renderEvent);
+    });
+
     eventSource.onerror = function (error) {
       console.error("Error en la conexión SSE:", error);
 
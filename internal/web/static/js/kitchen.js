@@ -0,0 +1,33 @@
+// Actualiza cada segundo el tiempo transcurrido de los tickets de cocina y
+// marca los que superaron su tiempo de preparación. El servidor renderiza el
+// valor inicial; el tablero se reemplaza con cada evento de orden.
+(function () {
+  const overdueClasses = ["bg-red-50", "border-red-500"];
+  const onTimeClasses = ["bg-white", "border-gray-200"];
+
+  function formatElapsed(seconds) {
+    const s = Math.max(0, seconds);
+    const minutes = String(Math.floor(s / 60)).padStart(2, "0");
+    return `${minutes}:${String(s % 60).padStart(2, "0")}`;
+  }
+
+  function updateTimers() {
+    const now = Date.now();
+    document.querySelectorAll(".kitchen-ticket").forEach((ticket) => {
+      const createdAt = Date.parse(ticket.dataset.createdAt);
+      const dueAt = Date.parse(ticket.dataset.dueAt);
+      const timer = ticket.querySelector(".ticket-timer");
+      if (timer) {
+        timer.textContent = formatElapsed(Math.floor((now - createdAt) / 1000));
+      }
+
+      const overdue = now > dueAt;
+      overdueClasses.forEach((c) => ticket.classList.toggle(c, overdue));
+      onTimeClasses.forEach((c) => ticket.classList.toggle(c, !overdue));
+    });
+  }
+
+  setInterval(updateTimers, 1000);
+  document.addEventListener("htmx:afterSwap", updateTimers);
+  document.addEventListener("DOMContentLoaded", updateTimers);
+})();
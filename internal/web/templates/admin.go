@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// AdminWeek es la grilla semanal de platos de la consola de administración
+type AdminWeek struct {
+	// Start es el lunes de la semana
+	Start        time.Time
+	Days         []AdminDay
+	Message      string
+	ErrorMessage string
+}
+
+// AdminDay es una columna de la grilla semanal, con los platos de un día
+type AdminDay struct {
+	Date   time.Time
+	Dishes []AdminDish
+}
+
+// AdminDish es un plato en la grilla semanal
+type AdminDish struct {
+	ID              string
+	Name            string
+	Price           utils.Money
+	PrepTimeMinutes int
+}
+
+// DishForm es el formulario para crear o editar un plato. Los campos se
+// guardan como texto para volver a mostrar lo que ingresó el usuario cuando
+// la validación falla.
+type DishForm struct {
+	// ID es vacío al crear un plato
+	ID              string
+	Name            string
+	Description     string
+	Price           string
+	PrepTimeMinutes string
+	AvailableOn     string
+	// Errors contiene los mensajes de validación por nombre de campo
+	Errors       map[string]string
+	ErrorMessage string
+}
+
+func (f DishForm) action() string {
+	if f.ID == "" {
+		return "/admin/dishes"
+	}
+	return "/admin/dishes/" + f.ID
+}
+
+func (f DishForm) title() string {
+	if f.ID == "" {
+		return "Nuevo plato"
+	}
+	return "Editar plato"
+}
+
+func (w AdminWeek) weekURL(offsetDays int) string {
+	return "/admin/dishes?week=" + w.Start.AddDate(0, 0, offsetDays).Format(dateFormat)
+}
+
+func (w AdminWeek) title() string {
+	end := w.Start.AddDate(0, 0, 6)
+	return fmt.Sprintf("Semana del %s al %s", w.Start.Format("02/01"), end.Format("02/01/2006"))
+}
+
+func fieldClass(form DishForm, field string) string {
+	if form.Errors[field] != "" {
+		return "w-full border rounded p-2 border-red-500"
+	}
+	return "w-full border rounded p-2"
+}
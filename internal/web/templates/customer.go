@@ -0,0 +1,96 @@
+package templates
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// MenuPage es la página del menú de un día con el formulario para ordenar
+type MenuPage struct {
+	Date time.Time
+	// Days son los días que se ofrecen en el selector de fecha
+	Days   []time.Time
+	Dishes []MenuDish
+	// IdempotencyKey identifica el envío del formulario, para que un doble
+	// envío no cree dos órdenes
+	IdempotencyKey string
+	ErrorMessage   string
+	// Preview muestra el menú como lo verán los clientes, sin el formulario
+	// para ordenar
+	Preview bool
+}
+
+// path es la ruta de la página, que cambia en la vista previa de la consola
+// de administración
+func (p MenuPage) path() string {
+	if p.Preview {
+		return "/admin/preview"
+	}
+	return "/menu"
+}
+
+// MenuDish es un plato del menú
+type MenuDish struct {
+	ID              string
+	Name            string
+	Description     string
+	Price           utils.Money
+	PrepTimeMinutes int
+}
+
+// CustomerOrder es una orden tal como la ve el cliente que la hizo
+type CustomerOrder struct {
+	ID        string
+	Status    string
+	Items     []CustomerOrderItem
+	Total     utils.Money
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CustomerOrderItem es una línea de una orden del cliente
+type CustomerOrderItem struct {
+	DishName  string
+	Quantity  int
+	UnitPrice utils.Money
+	Subtotal  utils.Money
+	Notes     string
+}
+
+// Active indica si la orden todavía puede cambiar de estado
+func (o CustomerOrder) Active() bool {
+	return o.Status != "served" && o.Status != "cancelled"
+}
+
+const dateFormat = "2006-01-02"
+
+// dayLabel es el nombre corto de un día en el selector de fecha
+func dayLabel(day time.Time) string {
+	return fmt.Sprintf("%s %d/%d", weekdayLabels[day.Weekday()], day.Day(), int(day.Month()))
+}
+
+var weekdayLabels = [...]string{"Dom", "Lun", "Mar", "Mié", "Jue", "Vie", "Sáb"}
+
+func dayClass(day, selected time.Time) string {
+	if day.Format(dateFormat) == selected.Format(dateFormat) {
+		return "px-3 py-1 rounded bg-blue-500 text-white"
+	}
+	return "px-3 py-1 rounded bg-white text-blue-600 border"
+}
+
+func formatPrice(price utils.Money) string {
+	return "$" + price.String()
+}
+
+func statusClass(status string) string {
+	switch status {
+	case "served":
+		return "px-2 py-1 text-xs rounded-full bg-green-100 text-green-800"
+	case "cancelled":
+		return "px-2 py-1 text-xs rounded-full bg-gray-200 text-gray-700"
+	default:
+		return "px-2 py-1 text-xs rounded-full bg-yellow-100 text-yellow-800"
+	}
+}
@@ -0,0 +1,85 @@
+package templates
+
+import (
+	"fmt"
+	"time"
+)
+
+// KitchenColumn es una columna del tablero de cocina, con los tickets en un
+// mismo estado
+type KitchenColumn struct {
+	Status  string
+	Tickets []KitchenTicket
+}
+
+// KitchenTicket es una orden tal como se muestra en el tablero de cocina
+type KitchenTicket struct {
+	ID              string
+	Status          string
+	Items           []KitchenItem
+	CreatedAt       time.Time
+	DueAt           time.Time
+	PrepTimeMinutes int
+	AgeSeconds      int64
+	Overdue         bool
+	// Next son los estados a los que se puede mover la orden
+	Next []string
+}
+
+// KitchenItem es una línea de un ticket de cocina
+type KitchenItem struct {
+	DishName string
+	Quantity int
+	Notes    string
+}
+
+// statusLabels son los nombres de los estados de una orden en la interfaz
+var statusLabels = map[string]string{
+	"received":  "Recibido",
+	"confirmed": "Confirmado",
+	"preparing": "Preparando",
+	"served":    "Servido",
+	"cancelled": "Cancelado",
+}
+
+// StatusLabel retorna el nombre de un estado para mostrar en la interfaz
+func StatusLabel(status string) string {
+	if label, ok := statusLabels[status]; ok {
+		return label
+	}
+	return status
+}
+
+// shortID abrevia un UUID para mostrarlo en los tickets
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// formatElapsed formatea segundos como mm:ss
+func formatElapsed(seconds int64) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+func prepTimeTitle(ticket KitchenTicket) string {
+	return fmt.Sprintf("Tiempo de preparación: %d min", ticket.PrepTimeMinutes)
+}
+
+func ticketClass(ticket KitchenTicket) string {
+	if ticket.Overdue {
+		return "kitchen-ticket border-2 rounded p-3 bg-red-50 border-red-500"
+	}
+	return "kitchen-ticket border-2 rounded p-3 bg-white border-gray-200"
+}
+
+func actionClass(status string) string {
+	if status == "cancelled" {
+		return "px-3 py-1 text-sm rounded bg-gray-200 text-gray-800"
+	}
+	return "px-3 py-1 text-sm rounded bg-blue-500 text-white"
+}
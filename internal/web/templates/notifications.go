@@ -0,0 +1,45 @@
+package templates
+
+import (
+	"net/url"
+	"time"
+)
+
+// NotificationsPage es una página de notificaciones del cliente
+type NotificationsPage struct {
+	Notifications []NotificationItem
+	// Cursor identifica la página actual; vacío en la primera
+	Cursor string
+	// NextCursor es vacío en la última página
+	NextCursor   string
+	Unread       int64
+	ErrorMessage string
+}
+
+// NotificationItem es una notificación de la lista
+type NotificationItem struct {
+	ID      string
+	OrderID string
+	Message string
+	SentAt  time.Time
+	Read    bool
+}
+
+// path es la ruta de la página, que se vuelve a pedir con cada evento
+func (p NotificationsPage) path() string {
+	return notificationsPath(p.Cursor)
+}
+
+func notificationsPath(cursor string) string {
+	if cursor == "" {
+		return "/my/notifications"
+	}
+	return "/my/notifications?cursor=" + url.QueryEscape(cursor)
+}
+
+func notificationClass(item NotificationItem) string {
+	if item.Read {
+		return "py-3 flex justify-between items-start gap-4 text-gray-500"
+	}
+	return "py-3 flex justify-between items-start gap-4 font-semibold"
+}
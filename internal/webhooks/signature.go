@@ -0,0 +1,46 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers de cada request de webhook
+const (
+	// HeaderEvent es el tipo del evento, por ejemplo "OrderCreated"
+	HeaderEvent = "X-TheMenu-Event"
+	// HeaderDelivery es el ID de la entrega; se mantiene en los reintentos y
+	// permite al destino descartar duplicados
+	HeaderDelivery = "X-TheMenu-Delivery"
+	// HeaderTimestamp es el instante del envío en segundos Unix
+	HeaderTimestamp = "X-TheMenu-Timestamp"
+	// HeaderSignature es la firma del request, ver Sign
+	HeaderSignature = "X-TheMenu-Signature"
+)
+
+// MinSecretLength es el largo mínimo de un secreto elegido por el administrador
+const MinSecretLength = 16
+
+// Sign firma el cuerpo de un request con HMAC-SHA256. El mensaje firmado es
+// "<timestamp>.<cuerpo>", de modo que el destino pueda rechazar requests
+// antiguos reenviados por un tercero. El resultado tiene la forma
+// "sha256=<hex>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret genera un secreto aleatorio para una suscripción
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
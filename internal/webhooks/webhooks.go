@@ -0,0 +1,303 @@
+// Package webhooks envía los eventos del sistema a las URLs registradas por
+// los administradores. El Worker escucha en el EventBus los eventos con
+// suscriptores, guarda una entrega por cada suscripción interesada y las envía
+// como POST firmados con el secreto de la suscripción, reintentando con
+// backoff exponencial. Las entregas que agotan los intentos quedan en la
+// lista de dead-letter hasta que un administrador las reenvía.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+// Estados de una entrega
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+const (
+	// PollInterval define cada cuánto el worker busca entregas pendientes
+	PollInterval = time.Second
+	// BatchSize define cuántas entregas se envían por ciclo
+	BatchSize = 20
+	// RequestTimeout es el tiempo máximo de espera de la respuesta del destino
+	RequestTimeout = 10 * time.Second
+	// LeaseDuration es el plazo por el que un worker toma un lote de entregas.
+	// Cubre un envío de RequestTimeout más el registro del resultado; las
+	// entregas del lote se envían en paralelo.
+	LeaseDuration = 2 * RequestTimeout
+	// MaxAttempts es la cantidad de intentos antes de pasar una entrega a la
+	// lista de dead-letter
+	MaxAttempts = 10
+	// BaseBackoff es la espera tras el primer intento fallido; se duplica en
+	// cada intento
+	BaseBackoff = 10 * time.Second
+	// MaxBackoff define la espera máxima entre intentos
+	MaxBackoff = time.Hour
+	// maxErrorBody es la cantidad de bytes de la respuesta guardados como error
+	maxErrorBody = 512
+)
+
+// SupportedEvents son los tipos de evento a los que se puede suscribir un
+// webhook
+var SupportedEvents = []string{
+	cqrs.EventOrderCreated,
+	cqrs.EventOrderStatusUpdated,
+	cqrs.EventDishUpdated,
+}
+
+// IsSupportedEvent indica si se puede suscribir un webhook al tipo de evento
+func IsSupportedEvent(eventType string) bool {
+	return slices.Contains(SupportedEvents, eventType)
+}
+
+// Payload es el cuerpo JSON de cada request de webhook
+type Payload struct {
+	// ID es el ID del evento, igual en todas las suscripciones
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+	// Data es el payload del evento, por ejemplo un OrderEventPayload
+	Data json.RawMessage `json:"data"`
+}
+
+// Worker registra y envía las entregas de webhooks
+type Worker struct {
+	store    database.Store
+	eventBus *cqrs.EventBus
+	client   *http.Client
+}
+
+// New crea un Worker
+func New(store database.Store, eventBus *cqrs.EventBus) *Worker {
+	return &Worker{
+		store:    store,
+		eventBus: eventBus,
+		client:   &http.Client{Timeout: RequestTimeout},
+	}
+}
+
+// Run registra las entregas de los eventos recibidos y envía las pendientes
+// hasta que se cancela el contexto. Los envíos se hacen en otra goroutine para
+// que un destino lento no retrase el registro de los eventos. Un evento cuyas
+// entregas no se pudieron registrar queda pendiente en el event bus y se
+// vuelve a entregar.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.deliverLoop(ctx)
+	}()
+	defer wg.Wait()
+
+	unsubscribe := w.eventBus.SubscribeHandler(cqrs.EventFilter{Types: SupportedEvents}, func(event cqrs.Event) error {
+		if err := w.Enqueue(ctx, event); err != nil {
+			return fmt.Errorf("error al registrar las entregas del evento %s: %w", event.ID, err)
+		}
+		return nil
+	})
+	defer unsubscribe()
+
+	<-ctx.Done()
+}
+
+// deliverLoop envía las entregas pendientes cada PollInterval
+func (w *Worker) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.DeliverPending(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Error al enviar los webhooks pendientes: %v", err)
+			}
+		}
+	}
+}
+
+// Enqueue registra una entrega del evento por cada suscripción activa que lo
+// escucha. Si falla el registro de alguna suscripción se intenta con las
+// demás y se retornan los errores juntos. Una suscripción que ya tiene una
+// entrega del evento no genera otra, por lo que un reintento sólo completa las
+// que faltan.
+func (w *Worker) Enqueue(ctx context.Context, event cqrs.Event) error {
+	eventID, err := uuid.Parse(event.ID)
+	if err != nil {
+		return fmt.Errorf("ID de evento inválido: %w", err)
+	}
+
+	subscriptions, err := w.store.ListWebhookSubscriptionsForEvent(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(Payload{
+		ID:        event.ID,
+		Type:      event.Type,
+		Status:    event.Status,
+		Timestamp: event.Timestamp,
+		Data:      json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return fmt.Errorf("payload inválido: %w", err)
+	}
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		if _, err := w.store.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+			ID:             utils.ToPgUUID(uuid.New()),
+			SubscriptionID: subscription.ID,
+			EventID:        utils.ToPgUUID(eventID),
+			EventType:      event.Type,
+			Payload:        body,
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("suscripción %s: %w", utils.FromPgUUID(subscription.ID), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeliverPending envía un lote de entregas pendientes. Las entregas del lote
+// se envían en paralelo, por lo que todas terminan dentro de RequestTimeout y
+// el lote completo cabe en el plazo por el que se toman (LeaseDuration); así
+// varias instancias del worker pueden ejecutarse sin enviar dos veces la
+// misma entrega.
+func (w *Worker) DeliverPending(ctx context.Context) error {
+	deliveries, err := w.store.ClaimWebhookDeliveries(ctx, database.ClaimWebhookDeliveriesParams{
+		LeaseSeconds: LeaseDuration.Seconds(),
+		BatchSize:    BatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(deliveries))
+	var wg sync.WaitGroup
+	for i, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = w.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// deliver envía una entrega y registra el intento. Sólo retorna error si no se
+// pudo guardar el resultado.
+func (w *Worker) deliver(ctx context.Context, delivery database.ClaimWebhookDeliveriesRow) error {
+	deliveryID := utils.FromPgUUID(delivery.ID)
+
+	start := time.Now()
+	statusCode, sendErr := w.send(ctx, delivery)
+	duration := time.Since(start)
+
+	attempt := database.CreateWebhookDeliveryAttemptParams{
+		DeliveryID: delivery.ID,
+		DurationMs: int32(duration.Milliseconds()),
+	}
+	if statusCode != 0 {
+		attempt.StatusCode = pgtype.Int4{Int32: int32(statusCode), Valid: true}
+	}
+	if sendErr != nil {
+		attempt.Error = utils.ToPgText(sendErr.Error())
+	}
+
+	return w.store.ExecTx(ctx, func(q database.Querier) error {
+		if err := q.CreateWebhookDeliveryAttempt(ctx, attempt); err != nil {
+			return err
+		}
+
+		if sendErr == nil {
+			return q.MarkWebhookDeliveryDelivered(ctx, database.MarkWebhookDeliveryDeliveredParams{
+				ID:             delivery.ID,
+				LastStatusCode: attempt.StatusCode,
+			})
+		}
+
+		attempts := delivery.Attempts + 1
+		status := StatusPending
+		if attempts >= MaxAttempts {
+			status = StatusDead
+			log.Printf("Webhook %s a %s descartado tras %d intentos: %v", deliveryID, delivery.Url, attempts, sendErr)
+		} else {
+			log.Printf("Error al enviar el webhook %s a %s (intento %d): %v", deliveryID, delivery.Url, attempts, sendErr)
+		}
+		return q.MarkWebhookDeliveryFailed(ctx, database.MarkWebhookDeliveryFailedParams{
+			ID:             delivery.ID,
+			Status:         status,
+			LastStatusCode: attempt.StatusCode,
+			LastError:      attempt.Error,
+			NextAttemptAt:  utils.ToPgTimestamp(time.Now().Add(Backoff(delivery.Attempts))),
+		})
+	})
+}
+
+// send hace el POST firmado. Retorna el código de la respuesta, o 0 si no
+// hubo respuesta, y un error si el código no es 2xx.
+func (w *Worker) send(ctx context.Context, delivery database.ClaimWebhookDeliveriesRow) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "TheMenu-Webhooks/1.0")
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderDelivery, utils.FromPgUUID(delivery.ID).String())
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+		return response.StatusCode, fmt.Errorf("respuesta %d: %s", response.StatusCode, bytes.TrimSpace(body))
+	}
+	// Se descarta el cuerpo para reutilizar la conexión
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxErrorBody))
+	return response.StatusCode, nil
+}
+
+// Backoff calcula la espera exponencial antes del siguiente intento, dada la
+// cantidad de intentos fallidos previos
+func Backoff(attempts int32) time.Duration {
+	backoff := BaseBackoff << min(attempts, 16)
+	if backoff > MaxBackoff {
+		return MaxBackoff
+	}
+	return backoff
+}
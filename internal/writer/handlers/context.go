@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/utils"
+)
+
+var (
+	errUnauthenticated = errors.New("usuario no autenticado")
+	errInvalidUserID   = errors.New("tipo de user_id inesperado")
+)
+
+// currentUserID obtiene el ID del usuario autenticado guardado en el contexto
+// por el middleware de autenticación
+func currentUserID(c *gin.Context) (uuid.UUID, error) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return uuid.Nil, errUnauthenticated
+	}
+
+	switch v := userID.(type) {
+	case uuid.UUID:
+		return v, nil
+	case pgtype.UUID:
+		return utils.FromPgUUID(v), nil
+	case string:
+		parsed, err := uuid.Parse(v)
+		if err != nil {
+			return uuid.Nil, errInvalidUserID
+		}
+		return parsed, nil
+	default:
+		return uuid.Nil, errInvalidUserID
+	}
+}
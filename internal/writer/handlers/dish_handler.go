@@ -1,78 +1,77 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/rodrwan/themenu/internal/cqrs"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/utils"
 )
 
 type DishHandler struct {
-	db       database.Querier
-	eventBus *cqrs.EventBus
+	db database.Store
 }
 
-func NewDishHandler(db database.Querier, eventBus *cqrs.EventBus) *DishHandler {
+func NewDishHandler(db database.Store) *DishHandler {
 	return &DishHandler{
-		db:       db,
-		eventBus: eventBus,
+		db: db,
 	}
 }
 
 // CreateDish maneja la creación de un nuevo plato
 func (h *DishHandler) CreateDish(c *gin.Context) {
 	var request struct {
-		Name            string    `json:"name" binding:"required"`
-		Description     string    `json:"description"`
-		Price           float64   `json:"price" binding:"required"`
-		PrepTimeMinutes int       `json:"prep_time_minutes" binding:"required"`
-		AvailableOn     time.Time `json:"available_on" binding:"required"`
+		Name            string      `json:"name" binding:"required,max=255"`
+		Description     string      `json:"description"`
+		Price           utils.Money `json:"price"`
+		PrepTimeMinutes int         `json:"prep_time_minutes" binding:"required,gt=0"`
+		AvailableOn     time.Time   `json:"available_on" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos"})
 		return
 	}
+	if request.Price.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El precio debe ser mayor que cero"})
+		return
+	}
 
-	// Crear el plato
+	// Crear el plato y registrar el evento DishCreated en la misma transacción
+	ctx := c.Request.Context()
 	dishID := uuid.New()
-	dish, err := h.db.CreateDish(c.Request.Context(), database.CreateDishParams{
-		ID:              utils.ToPgUUID(dishID),
-		Name:            request.Name,
-		Description:     utils.ToPgText(request.Description),
-		Price:           utils.ToPgNumeric(request.Price),
-		PrepTimeMinutes: int32(request.PrepTimeMinutes),
-		AvailableOn:     utils.ToPgDate(request.AvailableOn),
+	var payload cqrs.DishEventPayload
+	err := h.db.ExecTx(ctx, func(q database.Querier) error {
+		dish, err := q.CreateDish(ctx, database.CreateDishParams{
+			ID:              utils.ToPgUUID(dishID),
+			Name:            request.Name,
+			Description:     utils.ToPgText(request.Description),
+			Price:           utils.ToPgNumeric(request.Price),
+			PrepTimeMinutes: int32(request.PrepTimeMinutes),
+			AvailableOn:     utils.ToPgDate(request.AvailableOn),
+		})
+		if err != nil {
+			return err
+		}
+		if payload, err = dishEventPayload(dish); err != nil {
+			return err
+		}
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventDishCreated, "success", payload)
 	})
 	if err != nil {
+		log.Printf("Error al crear el plato: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el plato"})
 		return
 	}
 
-	// Publicar evento de plato creado
-	h.eventBus.PublishEvent(cqrs.EventDishCreated, "success", cqrs.DishEventPayload{
-		DishID:          dishID.String(),
-		Name:            dish.Name,
-		Description:     dish.Description.String,
-		Price:           utils.ToFloat64(dish.Price),
-		PrepTimeMinutes: int(dish.PrepTimeMinutes),
-		AvailableOn:     dish.AvailableOn.Time.Format(time.RFC3339),
-		Timestamp:       time.Now().Format(time.RFC3339),
-	})
-
-	c.JSON(http.StatusCreated, gin.H{
-		"id":                utils.FromPgUUID(dish.ID).String(),
-		"name":              dish.Name,
-		"description":       dish.Description.String,
-		"price":             utils.ToFloat64(dish.Price),
-		"prep_time_minutes": dish.PrepTimeMinutes,
-		"available_on":      dish.AvailableOn.Time,
-	})
+	c.JSON(http.StatusCreated, dishResponse(payload))
 }
 
 // UpdateDish maneja la actualización de un plato
@@ -84,51 +83,54 @@ func (h *DishHandler) UpdateDish(c *gin.Context) {
 	}
 
 	var request struct {
-		Name            string    `json:"name" binding:"required"`
-		Description     string    `json:"description"`
-		Price           float64   `json:"price" binding:"required"`
-		PrepTimeMinutes int       `json:"prep_time_minutes" binding:"required"`
-		AvailableOn     time.Time `json:"available_on" binding:"required"`
+		Name            string      `json:"name" binding:"required,max=255"`
+		Description     string      `json:"description"`
+		Price           utils.Money `json:"price"`
+		PrepTimeMinutes int         `json:"prep_time_minutes" binding:"required,gt=0"`
+		AvailableOn     time.Time   `json:"available_on" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos"})
 		return
 	}
+	if request.Price.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El precio debe ser mayor que cero"})
+		return
+	}
 
-	// Actualizar el plato
-	dish, err := h.db.UpdateDish(c.Request.Context(), database.UpdateDishParams{
-		ID:              utils.ToPgUUID(dishID),
-		Name:            request.Name,
-		Description:     utils.ToPgText(request.Description),
-		Price:           utils.ToPgNumeric(request.Price),
-		PrepTimeMinutes: int32(request.PrepTimeMinutes),
-		AvailableOn:     utils.ToPgDate(request.AvailableOn),
+	// Actualizar el plato y registrar el evento DishUpdated en la misma
+	// transacción
+	ctx := c.Request.Context()
+	var payload cqrs.DishEventPayload
+	err = h.db.ExecTx(ctx, func(q database.Querier) error {
+		dish, err := q.UpdateDish(ctx, database.UpdateDishParams{
+			ID:              utils.ToPgUUID(dishID),
+			Name:            request.Name,
+			Description:     utils.ToPgText(request.Description),
+			Price:           utils.ToPgNumeric(request.Price),
+			PrepTimeMinutes: int32(request.PrepTimeMinutes),
+			AvailableOn:     utils.ToPgDate(request.AvailableOn),
+		})
+		if err != nil {
+			return err
+		}
+		if payload, err = dishEventPayload(dish); err != nil {
+			return err
+		}
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventDishUpdated, "success", payload)
 	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plato no encontrado"})
+			return
+		}
+		log.Printf("Error al actualizar el plato: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el plato"})
 		return
 	}
 
-	// Publicar evento de plato actualizado
-	h.eventBus.PublishEvent(cqrs.EventDishUpdated, "success", cqrs.DishEventPayload{
-		DishID:          dishID.String(),
-		Name:            dish.Name,
-		Description:     dish.Description.String,
-		Price:           utils.ToFloat64(dish.Price),
-		PrepTimeMinutes: int(dish.PrepTimeMinutes),
-		AvailableOn:     dish.AvailableOn.Time.Format(time.RFC3339),
-		Timestamp:       time.Now().Format(time.RFC3339),
-	})
-
-	c.JSON(http.StatusOK, gin.H{
-		"id":                utils.FromPgUUID(dish.ID).String(),
-		"name":              dish.Name,
-		"description":       dish.Description.String,
-		"price":             utils.ToFloat64(dish.Price),
-		"prep_time_minutes": dish.PrepTimeMinutes,
-		"available_on":      dish.AvailableOn.Time,
-	})
+	c.JSON(http.StatusOK, dishResponse(payload))
 }
 
 // DeleteDish maneja la eliminación de un plato
@@ -139,30 +141,39 @@ func (h *DishHandler) DeleteDish(c *gin.Context) {
 		return
 	}
 
-	// Obtener el plato antes de eliminarlo para el evento
-	dish, err := h.db.GetDish(c.Request.Context(), utils.ToPgUUID(dishID))
+	// Eliminar el plato y registrar el evento DishDeleted, con los datos que
+	// tenía, en la misma transacción
+	ctx := c.Request.Context()
+	err = h.db.ExecTx(ctx, func(q database.Querier) error {
+		dish, err := q.GetDish(ctx, utils.ToPgUUID(dishID))
+		if err != nil {
+			return err
+		}
+		payload, err := dishEventPayload(dish)
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteDish(ctx, utils.ToPgUUID(dishID)); err != nil {
+			return err
+		}
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventDishDeleted, "success", payload)
+	})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Plato no encontrado"})
-		return
-	}
-
-	// Eliminar el plato
-	if err := h.db.DeleteDish(c.Request.Context(), utils.ToPgUUID(dishID)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Plato no encontrado"})
+			return
+		}
+		// Los platos que ya se ordenaron quedan referenciados por order_items
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == sqlStateForeignKeyViolation {
+			c.JSON(http.StatusConflict, gin.H{"error": "El plato tiene órdenes y no se puede eliminar"})
+			return
+		}
+		log.Printf("Error al eliminar el plato: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el plato"})
 		return
 	}
 
-	// Publicar evento de plato eliminado
-	h.eventBus.PublishEvent(cqrs.EventDishDeleted, "success", cqrs.DishEventPayload{
-		DishID:          dishID.String(),
-		Name:            dish.Name,
-		Description:     dish.Description.String,
-		Price:           utils.ToFloat64(dish.Price),
-		PrepTimeMinutes: int(dish.PrepTimeMinutes),
-		AvailableOn:     dish.AvailableOn.Time.Format(time.RFC3339),
-		Timestamp:       time.Now().Format(time.RFC3339),
-	})
-
 	c.JSON(http.StatusOK, gin.H{"message": "Plato eliminado exitosamente"})
 }
 
@@ -177,11 +188,18 @@ func (h *DishHandler) ListDishes(c *gin.Context) {
 
 	response := make([]gin.H, len(dishes))
 	for i, dish := range dishes {
+		price, err := utils.FromPgNumeric(dish.Price)
+		if err != nil {
+			log.Printf("Error al leer el precio del plato: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener los platos"})
+			return
+		}
 		response[i] = gin.H{
 			"id":                utils.FromPgUUID(dish.ID).String(),
 			"name":              dish.Name,
 			"description":       dish.Description.String,
-			"price":             utils.ToFloat64(dish.Price),
+			"price":             price,
+			"currency":          price.Currency,
 			"prep_time_minutes": dish.PrepTimeMinutes,
 			"available_on":      dish.AvailableOn.Time,
 			"created_at":        dish.CreatedAt.Time,
@@ -191,3 +209,99 @@ func (h *DishHandler) ListDishes(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// CloneWeek copia los platos de los 7 días anteriores a week_start en la
+// semana que comienza en week_start. Los platos que ya existen con el mismo
+// nombre en la fecha destino se omiten, por lo que repetir la copia es seguro.
+func (h *DishHandler) CloneWeek(c *gin.Context) {
+	var request struct {
+		WeekStart string `json:"week_start" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos"})
+		return
+	}
+
+	weekStart, err := time.Parse("2006-01-02", request.WeekStart)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Formato de fecha inválido"})
+		return
+	}
+
+	// Copiar los platos y registrar un DishCreated por cada copia en la misma
+	// transacción, para no anunciar una copia a medias
+	ctx := c.Request.Context()
+	var payloads []cqrs.DishEventPayload
+	err = h.db.ExecTx(ctx, func(q database.Querier) error {
+		dishes, err := q.CloneDishes(ctx, database.CloneDishesParams{
+			OffsetDays: 7,
+			FromDate:   utils.ToPgDate(weekStart.AddDate(0, 0, -7)),
+			ToDate:     utils.ToPgDate(weekStart.AddDate(0, 0, -1)),
+		})
+		if err != nil {
+			return err
+		}
+
+		payloads = make([]cqrs.DishEventPayload, len(dishes))
+		for i, dish := range dishes {
+			if payloads[i], err = dishEventPayload(dish); err != nil {
+				return err
+			}
+			if err := cqrs.EnqueueEvent(ctx, q, cqrs.EventDishCreated, "success", payloads[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error al copiar los platos de la semana anterior: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al copiar la semana anterior"})
+		return
+	}
+
+	response := make([]gin.H, len(payloads))
+	for i, payload := range payloads {
+		response[i] = dishResponse(payload)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"cloned": len(payloads),
+		"dishes": response,
+	})
+}
+
+// sqlStateForeignKeyViolation es el código SQLSTATE de una violación de
+// llave foránea
+const sqlStateForeignKeyViolation = "23503"
+
+// dishResponse arma la respuesta de un plato a partir de su evento, que ya
+// tiene el precio convertido
+func dishResponse(payload cqrs.DishEventPayload) gin.H {
+	return gin.H{
+		"id":                payload.DishID,
+		"name":              payload.Name,
+		"description":       payload.Description,
+		"price":             payload.Price,
+		"currency":          payload.Currency,
+		"prep_time_minutes": payload.PrepTimeMinutes,
+		"available_on":      payload.AvailableOn,
+	}
+}
+
+func dishEventPayload(dish database.Dish) (cqrs.DishEventPayload, error) {
+	price, err := utils.FromPgNumeric(dish.Price)
+	if err != nil {
+		return cqrs.DishEventPayload{}, err
+	}
+	return cqrs.DishEventPayload{
+		DishID:          utils.FromPgUUID(dish.ID).String(),
+		Name:            dish.Name,
+		Description:     dish.Description.String,
+		Price:           price,
+		Currency:        price.Currency,
+		PrepTimeMinutes: int(dish.PrepTimeMinutes),
+		AvailableOn:     dish.AvailableOn.Time.Format(time.RFC3339),
+		Timestamp:       time.Now().Format(time.RFC3339),
+	}, nil
+}
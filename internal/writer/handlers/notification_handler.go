@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rodrwan/themenu/internal/cqrs/commands"
+)
+
+type NotificationHandler struct {
+	commandBus commands.CommandDispatcher
+}
+
+func NewNotificationHandler(commandBus commands.CommandDispatcher) *NotificationHandler {
+	return &NotificationHandler{
+		commandBus: commandBus,
+	}
+}
+
+// MarkRead marca como leída una notificación del usuario autenticado
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de notificación inválido"})
+		return
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	cmd := commands.MarkNotificationReadCommand{
+		NotificationID: notificationID,
+		UserID:         userID,
+	}
+
+	result, err := commands.Dispatch[commands.MarkNotificationReadCommand, commands.NotificationsRead](c.Request.Context(), h.commandBus, cmd)
+	if err != nil {
+		if errors.Is(err, commands.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notificación no encontrada"})
+			return
+		}
+		log.Printf("Error al marcar la notificación como leída: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al marcar la notificación como leída"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// MarkAllRead marca como leídas todas las notificaciones del usuario
+// autenticado
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	cmd := commands.MarkAllNotificationsReadCommand{
+		UserID: userID,
+	}
+
+	result, err := commands.Dispatch[commands.MarkAllNotificationsReadCommand, commands.NotificationsRead](c.Request.Context(), h.commandBus, cmd)
+	if err != nil {
+		log.Printf("Error al marcar las notificaciones como leídas: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al marcar las notificaciones como leídas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rodrwan/themenu/internal/cqrs/commands"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
 )
 
 // UpdateOrderStatus actualiza el estado de una orden
@@ -27,16 +29,39 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	cmd := &commands.UpdateOrderStatusCommand{
+	actorID, err := currentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	cmd := commands.UpdateOrderStatusCommand{
 		OrderID: orderID,
 		Status:  req.Status,
+		ActorID: actorID,
 	}
 
-	if err := h.commandBus.Dispatch(cmd); err != nil {
+	change, err := commands.Dispatch[commands.UpdateOrderStatusCommand, commands.StatusChange](c.Request.Context(), h.commandBus, cmd)
+	if err != nil {
 		log.Printf("Error dispatching command: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, commands.ErrIllegalTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, commands.ErrTransitionForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, pipeline.ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, commands.ErrOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Orden no encontrada"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el estado de la orden"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Estado de la orden actualizado"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Estado de la orden actualizado",
+		"previous_status": change.Previous,
+		"status":          change.Status,
+	})
 }
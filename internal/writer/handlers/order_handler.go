@@ -1,30 +1,44 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rodrwan/themenu/internal/cqrs/commands"
-	"github.com/rodrwan/themenu/internal/utils"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
 )
 
 type OrderHandler struct {
 	commandBus commands.CommandDispatcher
+	// readerURL es la URL base del reader, donde se consultan las órdenes
+	// creadas. Si está vacía el header Location es relativo.
+	readerURL string
 }
 
-func NewOrderHandler(commandBus commands.CommandDispatcher) *OrderHandler {
+func NewOrderHandler(commandBus commands.CommandDispatcher, readerURL string) *OrderHandler {
 	return &OrderHandler{
 		commandBus: commandBus,
+		readerURL:  strings.TrimRight(readerURL, "/"),
 	}
 }
 
+// orderItemRequest es una línea del body de POST /orders
+type orderItemRequest struct {
+	DishID   string `json:"dish_id" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1,max=99"`
+	Notes    string `json:"notes" binding:"max=500"`
+}
+
 // CreateOrder maneja la creación de una nueva orden
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var request struct {
-		DishID string `json:"dish_id" binding:"required"`
+		Items []orderItemRequest `json:"items" binding:"omitempty,dive"`
+		// DishID se mantiene por compatibilidad: equivale a una línea con cantidad 1
+		DishID string `json:"dish_id"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -33,58 +47,59 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 
 	// Obtener el ID del usuario del contexto (asumiendo que viene del middleware de autenticación)
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+	userUUID, err := currentUserID(c)
+	if err != nil {
+		if err == errUnauthenticated {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			return
+		}
+		log.Printf("Error al obtener el usuario autenticado: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor"})
 		return
 	}
 
-	// Log temporal para depuración
-	log.Printf("userID (from context): %v, tipo: %T", userID, userID)
+	if len(request.Items) == 0 && request.DishID != "" {
+		request.Items = []orderItemRequest{{DishID: request.DishID, Quantity: 1}}
+	}
 
-	var userUUID uuid.UUID
-	switch v := userID.(type) {
-	case uuid.UUID:
-		userUUID = v
-	case pgtype.UUID:
-		userUUID = utils.FromPgUUID(v)
-	case string:
-		parsed, err := uuid.Parse(v)
+	// Convertir los IDs a UUID
+	items := make([]commands.OrderItem, len(request.Items))
+	for i, item := range request.Items {
+		dishUUID, err := uuid.Parse(item.DishID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor: user_id string inválido"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plato inválido"})
 			return
 		}
-		userUUID = parsed
-	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error interno del servidor: tipo de user_id inesperado"})
-		return
-	}
-
-	// Convertir los IDs a UUID
-	dishUUID, err := uuid.Parse(request.DishID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plato inválido"})
-		return
+		items[i] = commands.OrderItem{
+			DishID:   dishUUID,
+			Quantity: item.Quantity,
+			Notes:    item.Notes,
+		}
 	}
 
 	// Crear y ejecutar el comando
-	cmd := &commands.CreateOrderCommand{
-		UserID:  userUUID,
-		DishID:  dishUUID,
-		Queries: nil, // Se establecerá en el handler
+	cmd := commands.CreateOrderCommand{
+		UserID: userUUID,
+		Items:  items,
+		Store:  nil, // Se establecerá en el handler
 	}
 
-	if err := h.commandBus.Dispatch(cmd); err != nil {
-		switch err {
-		case commands.ErrOrderExists:
+	order, err := commands.Dispatch[commands.CreateOrderCommand, commands.OrderResult](c.Request.Context(), h.commandBus, cmd)
+	if err != nil {
+		switch {
+		case errors.Is(err, commands.ErrOrderExists):
 			c.JSON(http.StatusConflict, gin.H{"error": "Ya tienes una orden activa"})
-		case commands.ErrDishNotFound:
+		case errors.Is(err, commands.ErrDishNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "Plato no encontrado"})
+		case errors.Is(err, commands.ErrEmptyOrder), errors.Is(err, commands.ErrInvalidItem), errors.Is(err, pipeline.ErrValidation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
+			log.Printf("Error al crear la orden: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear la orden"})
 		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Orden creada exitosamente"})
+	c.Header("Location", h.readerURL+"/orders/"+order.ID)
+	c.JSON(http.StatusCreated, order)
 }
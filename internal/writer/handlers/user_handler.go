@@ -1,60 +1,96 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rodrwan/themenu/internal/auth"
 	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/cqrs/commands"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/utils"
 )
 
 type UserHandler struct {
-	db       database.Querier
-	eventBus *cqrs.EventBus
+	db     database.Store
+	tokens *auth.Manager
 }
 
-func NewUserHandler(db database.Querier, eventBus *cqrs.EventBus) *UserHandler {
+func NewUserHandler(db database.Store, tokens *auth.Manager) *UserHandler {
 	return &UserHandler{
-		db:       db,
-		eventBus: eventBus,
+		db:     db,
+		tokens: tokens,
 	}
 }
 
 // CreateUser maneja la creación de un nuevo usuario
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var request struct {
-		Name  string `json:"name" binding:"required"`
-		Email string `json:"email" binding:"required,email"`
+		Name     string `json:"name" binding:"required"`
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos"})
 		return
 	}
+	if len(request.Password) < auth.MinPasswordLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("La contraseña debe tener al menos %d caracteres", auth.MinPasswordLength)})
+		return
+	}
+	passwordHash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Contraseña inválida"})
+		return
+	}
 
-	// Crear el usuario
+	// Crear el usuario con el rol client y registrar el evento UserCreated en
+	// la misma transacción
+	ctx := c.Request.Context()
 	userID := uuid.New()
-	user, err := h.db.CreateUser(c.Request.Context(), database.CreateUserParams{
-		ID:    utils.ToPgUUID(userID),
-		Name:  request.Name,
-		Email: request.Email,
+	var user database.User
+	err = h.db.ExecTx(ctx, func(q database.Querier) error {
+		var err error
+		user, err = q.CreateUser(ctx, database.CreateUserParams{
+			ID:           utils.ToPgUUID(userID),
+			Name:         request.Name,
+			Email:        request.Email,
+			PasswordHash: utils.ToPgText(passwordHash),
+		})
+		if err != nil {
+			return err
+		}
+		// Sin un rol el usuario no tendría permisos para ver el menú ni ordenar
+		assigned, err := q.AssignUserRole(ctx, database.AssignUserRoleParams{
+			UserID:   user.ID,
+			RoleName: commands.RoleClient,
+		})
+		if err != nil {
+			return err
+		}
+		if assigned == 0 {
+			return fmt.Errorf("el rol %s no existe", commands.RoleClient)
+		}
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventUserCreated, "success", cqrs.UserEventPayload{
+			UserID:    userID.String(),
+			Name:      user.Name,
+			Email:     user.Email,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
 	})
 	if err != nil {
+		log.Printf("Error al crear el usuario: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el usuario"})
 		return
 	}
 
-	// Publicar evento de usuario creado
-	h.eventBus.PublishEvent(cqrs.EventUserCreated, "success", cqrs.UserEventPayload{
-		UserID:    userID.String(),
-		Name:      user.Name,
-		Email:     user.Email,
-		Timestamp: time.Now().Format(time.RFC3339),
-	})
-
 	c.JSON(http.StatusCreated, gin.H{
 		"id":    utils.FromPgUUID(user.ID).String(),
 		"name":  user.Name,
@@ -70,35 +106,76 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Sólo se puede editar el propio perfil, salvo con el permiso manage_users
+	if currentUserID, _ := c.Get("user_id"); currentUserID != utils.ToPgUUID(userID) {
+		permissions, err := auth.Permissions(c, h.db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al verificar permisos"})
+			return
+		}
+		if !permissions.Has(auth.PermManageUsers) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permiso insuficiente", "permission": auth.PermManageUsers})
+			return
+		}
+	}
+
 	var request struct {
 		Name  string `json:"name" binding:"required"`
 		Email string `json:"email" binding:"required,email"`
+		// Password es opcional; si se envía reemplaza la contraseña
+		Password string `json:"password"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos"})
 		return
 	}
+	var passwordHash string
+	if request.Password != "" {
+		if len(request.Password) < auth.MinPasswordLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("La contraseña debe tener al menos %d caracteres", auth.MinPasswordLength)})
+			return
+		}
+		if passwordHash, err = auth.HashPassword(request.Password); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Contraseña inválida"})
+			return
+		}
+	}
 
-	// Actualizar el usuario
-	user, err := h.db.UpdateUser(c.Request.Context(), database.UpdateUserParams{
-		ID:    utils.ToPgUUID(userID),
-		Name:  request.Name,
-		Email: request.Email,
+	// Actualizar el usuario y registrar el evento UserUpdated en la misma
+	// transacción
+	ctx := c.Request.Context()
+	var user database.User
+	err = h.db.ExecTx(ctx, func(q database.Querier) error {
+		var err error
+		user, err = q.UpdateUser(ctx, database.UpdateUserParams{
+			ID:    utils.ToPgUUID(userID),
+			Name:  request.Name,
+			Email: request.Email,
+		})
+		if err != nil {
+			return err
+		}
+		if passwordHash != "" {
+			if _, err := q.SetUserPassword(ctx, database.SetUserPasswordParams{
+				ID:           user.ID,
+				PasswordHash: utils.ToPgText(passwordHash),
+			}); err != nil {
+				return err
+			}
+		}
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventUserUpdated, "success", cqrs.UserEventPayload{
+			UserID:    userID.String(),
+			Name:      user.Name,
+			Email:     user.Email,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el usuario"})
 		return
 	}
 
-	// Publicar evento de usuario actualizado
-	h.eventBus.PublishEvent(cqrs.EventUserUpdated, "success", cqrs.UserEventPayload{
-		UserID:    userID.String(),
-		Name:      user.Name,
-		Email:     user.Email,
-		Timestamp: time.Now().Format(time.RFC3339),
-	})
-
 	c.JSON(http.StatusOK, gin.H{
 		"id":    utils.FromPgUUID(user.ID).String(),
 		"name":  user.Name,
@@ -106,10 +183,12 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	})
 }
 
-// GenerateToken maneja la generación de un token de acceso
+// GenerateToken maneja la generación de un token de acceso a partir del email
+// y la contraseña del usuario
 func (h *UserHandler) GenerateToken(c *gin.Context) {
 	var request struct {
-		Email string `json:"email" binding:"required,email"`
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -117,22 +196,43 @@ func (h *UserHandler) GenerateToken(c *gin.Context) {
 		return
 	}
 
-	// Buscar el usuario por email
+	// Buscar el usuario por email y verificar su contraseña. Un usuario
+	// inexistente o sin contraseña responde igual que una contraseña
+	// incorrecta.
 	user, err := h.db.GetUserByEmail(c.Request.Context(), request.Email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("Error al buscar el usuario: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el token"})
+		return
+	}
+	if err := auth.CheckPassword(user.PasswordHash.String, request.Password); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Email o contraseña incorrectos"})
+		return
+	}
+
+	// Generar un token JWT firmado
+	userID := utils.FromPgUUID(user.ID)
+	token, expiresAt, err := h.tokens.Issue(userID, user.Email)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no encontrado"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar el token"})
 		return
 	}
 
-	// Generar un token (en este caso, el ID del usuario)
-	token := utils.FromPgUUID(user.ID).String()
+	// Registrar el evento de token generado
+	ctx := c.Request.Context()
+	if err := h.db.ExecTx(ctx, func(q database.Querier) error {
+		return cqrs.EnqueueEvent(ctx, q, cqrs.EventTokenGenerated, "success", cqrs.TokenEventPayload{
+			UserID:    userID.String(),
+			Email:     user.Email,
+			Timestamp: time.Now().Format(time.RFC3339),
+		})
+	}); err != nil {
+		log.Printf("Error al registrar el evento TokenGenerated: %v", err)
+	}
 
-	// Publicar evento de token generado
-	h.eventBus.PublishEvent(cqrs.EventTokenGenerated, "success", cqrs.TokenEventPayload{
-		UserID:    token,
-		Email:     user.Email,
-		Timestamp: time.Now().Format(time.RFC3339),
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_at": expiresAt,
 	})
-
-	c.JSON(http.StatusOK, gin.H{"token": token})
 }
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rodrwan/themenu/internal/database"
+	"github.com/rodrwan/themenu/internal/utils"
+	"github.com/rodrwan/themenu/internal/webhooks"
+)
+
+type WebhookHandler struct {
+	db database.Querier
+}
+
+func NewWebhookHandler(db database.Querier) *WebhookHandler {
+	return &WebhookHandler{
+		db: db,
+	}
+}
+
+// CreateSubscription registra una suscripción de webhook. Si no se indica un
+// secreto se genera uno; la respuesta es la única que incluye el secreto.
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var request struct {
+		URL        string   `json:"url" binding:"required"`
+		EventTypes []string `json:"event_types" binding:"required,min=1"`
+		Secret     string   `json:"secret"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos"})
+		return
+	}
+	eventTypes, message := validateWebhook(request.URL, request.EventTypes)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "supported_events": webhooks.SupportedEvents})
+		return
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		generated, err := webhooks.NewSecret()
+		if err != nil {
+			log.Printf("Error al generar el secreto del webhook: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el webhook"})
+			return
+		}
+		secret = generated
+	} else if len(secret) < webhooks.MinSecretLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El secreto debe tener al menos 16 caracteres"})
+		return
+	}
+
+	subscription, err := h.db.CreateWebhookSubscription(c.Request.Context(), database.CreateWebhookSubscriptionParams{
+		ID:         utils.ToPgUUID(uuid.New()),
+		Url:        request.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+	})
+	if err != nil {
+		log.Printf("Error al crear el webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el webhook"})
+		return
+	}
+
+	response := webhookSubscriptionResponse(subscription)
+	response["secret"] = subscription.Secret
+	c.JSON(http.StatusCreated, response)
+}
+
+// UpdateSubscription cambia la URL, los eventos o el estado de una
+// suscripción. Una suscripción inactiva no recibe entregas nuevas y sus
+// entregas pendientes esperan a que se reactive.
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de webhook inválido"})
+		return
+	}
+
+	var request struct {
+		URL        string   `json:"url" binding:"required"`
+		EventTypes []string `json:"event_types" binding:"required,min=1"`
+		Active     *bool    `json:"active" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos"})
+		return
+	}
+	eventTypes, message := validateWebhook(request.URL, request.EventTypes)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message, "supported_events": webhooks.SupportedEvents})
+		return
+	}
+
+	subscription, err := h.db.UpdateWebhookSubscription(c.Request.Context(), database.UpdateWebhookSubscriptionParams{
+		ID:         utils.ToPgUUID(subscriptionID),
+		Url:        request.URL,
+		EventTypes: eventTypes,
+		Active:     *request.Active,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook no encontrado"})
+			return
+		}
+		log.Printf("Error al actualizar el webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhookSubscriptionResponse(subscription))
+}
+
+// DeleteSubscription elimina una suscripción junto con sus entregas
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	subscriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de webhook inválido"})
+		return
+	}
+
+	deleted, err := h.db.DeleteWebhookSubscription(c.Request.Context(), utils.ToPgUUID(subscriptionID))
+	if err != nil {
+		log.Printf("Error al eliminar el webhook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el webhook"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook no encontrado"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook eliminado exitosamente"})
+}
+
+// Redeliver vuelve a encolar una entrega, por ejemplo una de la lista de
+// dead-letter, con todos sus intentos disponibles. El worker la envía en su
+// siguiente ciclo.
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	deliveryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de entrega inválido"})
+		return
+	}
+
+	delivery, err := h.db.RedeliverWebhookDelivery(c.Request.Context(), utils.ToPgUUID(deliveryID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entrega no encontrada"})
+			return
+		}
+		log.Printf("Error al reenviar la entrega: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al reenviar la entrega"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"id":              utils.FromPgUUID(delivery.ID).String(),
+		"subscription_id": utils.FromPgUUID(delivery.SubscriptionID).String(),
+		"event_id":        utils.FromPgUUID(delivery.EventID).String(),
+		"event_type":      delivery.EventType,
+		"status":          delivery.Status,
+		"next_attempt_at": delivery.NextAttemptAt.Time,
+	})
+}
+
+// validateWebhook valida la URL y los tipos de evento de una suscripción y
+// retorna los tipos sin repetir. Si son inválidos retorna el mensaje de error.
+func validateWebhook(rawURL string, eventTypes []string) ([]string, string) {
+	target, err := url.ParseRequestURI(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, "La URL debe ser una dirección http o https absoluta"
+	}
+
+	unique := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !webhooks.IsSupportedEvent(eventType) {
+			return nil, "Tipo de evento no soportado: " + eventType
+		}
+		if !slices.Contains(unique, eventType) {
+			unique = append(unique, eventType)
+		}
+	}
+	return unique, ""
+}
+
+// webhookSubscriptionResponse construye la respuesta JSON de una suscripción,
+// sin el secreto
+func webhookSubscriptionResponse(subscription database.WebhookSubscription) gin.H {
+	return gin.H{
+		"id":          utils.FromPgUUID(subscription.ID).String(),
+		"url":         subscription.Url,
+		"event_types": subscription.EventTypes,
+		"active":      subscription.Active,
+		"created_at":  subscription.CreatedAt.Time,
+		"updated_at":  subscription.UpdatedAt.Time,
+	}
+}
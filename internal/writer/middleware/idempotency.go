@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rodrwan/themenu/internal/database"
+)
+
+const (
+	// IdempotencyHeader es el header con el que el cliente identifica un request
+	// que puede reintentar
+	IdempotencyHeader = "Idempotency-Key"
+	// IdempotencyReplayedHeader marca las respuestas reenviadas desde una llave
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+	// DefaultIdempotencyTTL es el tiempo que se guarda la respuesta de una llave
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// IdempotencyLease es el tiempo máximo que una llave queda reservada sin
+	// respuesta. Pasado ese plazo el request original se considera abandonado
+	// (por ejemplo, el writer se cayó) y un reintento puede volver a
+	// ejecutarlo.
+	IdempotencyLease = time.Minute
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders son los headers de la respuesta original que se guardan y
+// se reenvían junto con el cuerpo
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// Idempotency hace que los requests con Idempotency-Key se ejecuten una sola
+// vez por usuario. La primera respuesta se guarda en Postgres durante ttl y
+// los reintentos con la misma llave la reciben sin volver a despachar el
+// comando. Reusar la llave con otro método, ruta o cuerpo responde 422, y un
+// reintento mientras el original sigue en curso responde 409. Las respuestas
+// 5xx y los panics liberan la llave para que el cliente pueda reintentar, y
+// una reserva sin respuesta por más de IdempotencyLease se reutiliza. Debe
+// usarse después de auth.Middleware.
+func Idempotency(db database.Querier, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key demasiado larga"})
+			c.Abort()
+			return
+		}
+
+		userID, ok := c.Get("user_id")
+		pgUserID, isUUID := userID.(pgtype.UUID)
+		if !ok || !isUUID {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el cuerpo del request"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		reserved, err := db.ReserveIdempotencyKey(ctx, database.ReserveIdempotencyKeyParams{
+			UserID:       pgUserID,
+			Key:          key,
+			Fingerprint:  fingerprint,
+			TtlSeconds:   ttl.Seconds(),
+			LeaseSeconds: IdempotencyLease.Seconds(),
+		})
+		if err != nil {
+			log.Printf("Error al reservar la Idempotency-Key: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar la Idempotency-Key"})
+			c.Abort()
+			return
+		}
+
+		if reserved == 0 {
+			replay(c, db, pgUserID, key, fingerprint)
+			return
+		}
+
+		// La respuesta se guarda aunque el cliente ya se haya desconectado,
+		// que es justamente el caso que origina los reintentos
+		storeCtx := context.WithoutCancel(ctx)
+		release := func() {
+			if err := db.DeleteIdempotencyKey(storeCtx, database.DeleteIdempotencyKeyParams{UserID: pgUserID, Key: key}); err != nil {
+				log.Printf("Error al liberar la Idempotency-Key: %v", err)
+			}
+		}
+
+		// Si el handler entra en pánico la llave se libera antes de que el
+		// panic llegue al middleware de recovery
+		defer func() {
+			if r := recover(); r != nil {
+				release()
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			release()
+			return
+		}
+
+		headers := make(map[string]string, len(replayedHeaders))
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		encodedHeaders, err := json.Marshal(headers)
+		if err != nil {
+			log.Printf("Error al serializar los headers de la respuesta: %v", err)
+			return
+		}
+
+		err = db.CompleteIdempotencyKey(storeCtx, database.CompleteIdempotencyKeyParams{
+			UserID:          pgUserID,
+			Key:             key,
+			StatusCode:      pgtype.Int4{Int32: int32(status), Valid: true},
+			ResponseBody:    recorder.body.Bytes(),
+			ResponseHeaders: encodedHeaders,
+		})
+		if err != nil {
+			log.Printf("Error al guardar la respuesta de la Idempotency-Key: %v", err)
+		}
+	}
+}
+
+// replay responde un request cuya llave ya estaba reservada
+func replay(c *gin.Context, db database.Querier, userID pgtype.UUID, key, fingerprint string) {
+	defer c.Abort()
+
+	stored, err := db.GetIdempotencyKey(c.Request.Context(), database.GetIdempotencyKeyParams{UserID: userID, Key: key})
+	if err != nil {
+		// La llave se liberó entre la reserva y la lectura (el request
+		// original falló); el cliente puede reintentar
+		c.JSON(http.StatusConflict, gin.H{"error": "Hay un request en curso con la misma Idempotency-Key"})
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "La Idempotency-Key ya se usó con un request distinto"})
+		return
+	}
+
+	if !stored.StatusCode.Valid {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hay un request en curso con la misma Idempotency-Key"})
+		return
+	}
+
+	var headers map[string]string
+	if len(stored.ResponseHeaders) > 0 {
+		if err := json.Unmarshal(stored.ResponseHeaders, &headers); err != nil {
+			log.Printf("Error al leer los headers guardados de la Idempotency-Key: %v", err)
+		}
+	}
+	for name, value := range headers {
+		c.Header(name, value)
+	}
+	c.Header(IdempotencyReplayedHeader, "true")
+
+	c.Data(int(stored.StatusCode.Int32), headers["Content-Type"], stored.ResponseBody)
+}
+
+// requestFingerprint identifica el contenido de un request: el mismo método,
+// ruta y cuerpo producen el mismo fingerprint
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copia el cuerpo de la respuesta mientras se escribe
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// PurgeIdempotencyKeys elimina periódicamente las llaves expiradas hasta que
+// se cancele el contexto
+func PurgeIdempotencyKeys(ctx context.Context, db database.Querier, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := db.DeleteExpiredIdempotencyKeys(ctx)
+			if err != nil {
+				log.Printf("Error al eliminar Idempotency-Keys expiradas: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Eliminadas %d Idempotency-Keys expiradas", deleted)
+			}
+		}
+	}
+}
@@ -1,29 +1,45 @@
 package writer
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
-	"github.com/rodrwan/themenu/internal/cqrs"
+	"github.com/rodrwan/themenu/internal/auth"
 	"github.com/rodrwan/themenu/internal/cqrs/commands"
+	"github.com/rodrwan/themenu/internal/cqrs/pipeline"
 	"github.com/rodrwan/themenu/internal/database"
 	"github.com/rodrwan/themenu/internal/writer/handlers"
 	"github.com/rodrwan/themenu/internal/writer/middleware"
 )
 
+// RequiredCommands son los comandos que despachan los handlers del servidor.
+// El bus debe tener un handler para cada uno antes de iniciar.
+var RequiredCommands = []commands.Requirement{
+	commands.Require[commands.CreateOrderCommand, commands.OrderResult](),
+	commands.Require[commands.UpdateOrderStatusCommand, commands.StatusChange](),
+	commands.Require[commands.MarkNotificationReadCommand, commands.NotificationsRead](),
+	commands.Require[commands.MarkAllNotificationsReadCommand, commands.NotificationsRead](),
+}
+
 // Server representa el servidor HTTP
 type Server struct {
 	router     *gin.Engine
 	commandBus commands.CommandDispatcher
-	db         database.Querier
-	eventBus   *cqrs.EventBus
+	db         database.Store
+	tokens     *auth.Manager
+	latency    *pipeline.LatencyStats
+	readerURL  string
 }
 
 // NewServer crea una nueva instancia del servidor
-func NewServer(commandBus commands.CommandDispatcher, db database.Querier, eventBus *cqrs.EventBus) *Server {
+func NewServer(commandBus commands.CommandDispatcher, db database.Store, tokens *auth.Manager, latency *pipeline.LatencyStats, readerURL string) *Server {
 	server := &Server{
 		router:     gin.Default(),
 		commandBus: commandBus,
 		db:         db,
-		eventBus:   eventBus,
+		tokens:     tokens,
+		latency:    latency,
+		readerURL:  readerURL,
 	}
 
 	server.setupRoutes()
@@ -36,35 +52,66 @@ func (s *Server) setupRoutes() {
 	s.router.Use(middleware.LoggerMiddleware())
 
 	// Rutas públicas (sin autenticación)
-	userHandler := handlers.NewUserHandler(s.db, s.eventBus)
+	userHandler := handlers.NewUserHandler(s.db, s.tokens)
 	s.router.POST("/users", userHandler.CreateUser)
 	s.router.POST("/users/token", userHandler.GenerateToken)
 
 	// Aplicar middleware de autenticación para el resto de rutas
-	s.router.Use(middleware.AuthMiddleware(s.db))
+	s.router.Use(auth.Middleware(s.tokens, s.db))
 
 	// Rutas protegidas
-	orderHandler := handlers.NewOrderHandler(s.commandBus)
+	orderHandler := handlers.NewOrderHandler(s.commandBus, s.readerURL)
+	idempotent := middleware.Idempotency(s.db, middleware.DefaultIdempotencyTTL)
 	orders := s.router.Group("/orders")
 	{
-		orders.POST("", orderHandler.CreateOrder)
-		orders.PATCH("/:id/status", orderHandler.UpdateOrderStatus)
+		orders.POST("", auth.RequirePermission(s.db, auth.PermPlaceOrder), idempotent, orderHandler.CreateOrder)
+		// El dueño de una orden puede cancelarla; la máquina de estados del
+		// comando decide qué transiciones puede hacer cada usuario
+		orders.PATCH("/:id/status", auth.RequireAnyPermission(s.db, auth.PermUpdateOrderStatus, auth.PermPlaceOrder), idempotent, orderHandler.UpdateOrderStatus)
+	}
+
+	// Notificaciones del usuario autenticado
+	notificationHandler := handlers.NewNotificationHandler(s.commandBus)
+	notifications := s.router.Group("/notifications")
+	{
+		notifications.POST("/read", notificationHandler.MarkAllRead)
+		notifications.POST("/:id/read", notificationHandler.MarkRead)
 	}
 
-	// Rutas de usuario
+	// Rutas de usuario (cada usuario puede editar su propio perfil; editar
+	// otros usuarios requiere manage_users, ver UserHandler.UpdateUser)
 	users := s.router.Group("/users")
 	{
 		users.PATCH("/:id", userHandler.UpdateUser)
 	}
 
 	// Rutas de platos
-	dishHandler := handlers.NewDishHandler(s.db, s.eventBus)
-	dishes := s.router.Group("/dishes")
+	dishHandler := handlers.NewDishHandler(s.db)
+	dishes := s.router.Group("/dishes", auth.RequirePermission(s.db, auth.PermManageDishes))
 	{
 		dishes.POST("", dishHandler.CreateDish)
+		dishes.POST("/clone-week", dishHandler.CloneWeek)
 		dishes.PUT("/:id", dishHandler.UpdateDish)
 		dishes.DELETE("/:id", dishHandler.DeleteDish)
 	}
+
+	// Webhooks salientes (administración)
+	webhookHandler := handlers.NewWebhookHandler(s.db)
+	webhooks := s.router.Group("/webhooks", auth.RequirePermission(s.db, auth.PermManageWebhooks))
+	{
+		webhooks.POST("/subscriptions", webhookHandler.CreateSubscription)
+		webhooks.PUT("/subscriptions/:id", webhookHandler.UpdateSubscription)
+		webhooks.DELETE("/subscriptions/:id", webhookHandler.DeleteSubscription)
+		webhooks.POST("/deliveries/:id/redeliver", webhookHandler.Redeliver)
+	}
+
+	// Latencias acumuladas por el bus, para diagnóstico
+	s.router.GET("/debug/latency", auth.RequirePermission(s.db, auth.PermManageUsers), s.handleLatency)
+}
+
+// handleLatency responde con las latencias acumuladas por tipo de mensaje
+func (s *Server) handleLatency(c *gin.Context) {
+	c.JSON(http.StatusOK, s.latency.Snapshot())
 }
 
 // Start inicia el servidor
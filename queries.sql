@@ -7,8 +7,8 @@ SELECT * FROM users
 WHERE email = $1 LIMIT 1;
 
 -- name: CreateUser :one
-INSERT INTO users (id, name, email)
-VALUES ($1, $2, $3) RETURNING *;
+INSERT INTO users (id, name, email, password_hash)
+VALUES ($1, $2, $3, $4) RETURNING *;
 
 -- name: UpdateUser :one
 UPDATE users
@@ -16,6 +16,11 @@ SET name = $2, email = $3
 WHERE id = $1
 RETURNING *;
 
+-- name: SetUserPassword :execrows
+UPDATE users
+SET password_hash = $2
+WHERE id = $1;
+
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1;
@@ -65,27 +70,58 @@ SELECT * FROM orders
 WHERE id = $1 LIMIT 1;
 
 -- name: CreateOrder :one
-INSERT INTO orders (id, user_id, dish_id, status)
-VALUES ($1, $2, $3, $4) RETURNING *;
+INSERT INTO orders (id, user_id, status)
+VALUES ($1, $2, $3) RETURNING *;
 
--- name: GetOrdersByUserId :many
+-- name: CreateOrderItem :one
+INSERT INTO order_items (id, order_id, dish_id, quantity, unit_price, notes)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: GetOrderItemsByOrderIds :many
 SELECT
-    o.*,
+    oi.*,
     d.name as dish_name,
     d.description as dish_description,
-    d.price as dish_price
-FROM orders o
-JOIN dishes d ON o.dish_id = d.id
-WHERE o.user_id = $1;
+    d.prep_time_minutes as dish_prep_time_minutes
+FROM order_items oi
+JOIN dishes d ON oi.dish_id = d.id
+WHERE oi.order_id = ANY(sqlc.arg(order_ids)::uuid[])
+ORDER BY oi.created_at, oi.id;
 
--- name: GetOrdersByDishId :many
+-- name: GetOrdersByUserId :many
 SELECT * FROM orders
-WHERE dish_id = $1;
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetOrdersByDishId :many
+SELECT DISTINCT o.* FROM orders o
+JOIN order_items oi ON oi.order_id = o.id
+WHERE oi.dish_id = $1;
 
 -- name: GetOrdersByStatus :many
 SELECT * FROM orders
 WHERE status = $1;
 
+-- name: ListOrders :many
+-- Los filtros en NULL no se aplican. El rango de fechas es [created_from, created_to).
+SELECT o.* FROM orders o
+WHERE (sqlc.narg(statuses)::text[] IS NULL OR o.status = ANY(sqlc.narg(statuses)::text[]))
+  AND (sqlc.narg(user_id)::uuid IS NULL OR o.user_id = sqlc.narg(user_id)::uuid)
+  AND (sqlc.narg(dish_id)::uuid IS NULL OR EXISTS (
+        SELECT 1 FROM order_items oi
+        WHERE oi.order_id = o.id AND oi.dish_id = sqlc.narg(dish_id)::uuid
+      ))
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR o.created_at >= sqlc.narg(created_from)::timestamp)
+  AND (sqlc.narg(created_to)::timestamp IS NULL OR o.created_at < sqlc.narg(created_to)::timestamp)
+ORDER BY o.created_at DESC, o.id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: GetKitchenQueue :many
+-- Órdenes activas, de la más antigua a la más reciente
+SELECT * FROM orders
+WHERE status NOT IN ('served', 'cancelled')
+ORDER BY created_at, id;
+
 -- name: GetNotificationsByUserId :many
 SELECT * FROM notifications
 WHERE user_id = $1;
@@ -94,6 +130,45 @@ WHERE user_id = $1;
 INSERT INTO notifications (id, user_id, order_id, message)
 VALUES ($1, $2, $3, $4) RETURNING *;
 
+-- name: GetNotificationChannels :many
+-- Canales por los que ya se entregó la notificación
+SELECT channel FROM notification_deliveries
+WHERE notification_id = $1;
+
+-- name: RecordNotificationDelivery :exec
+INSERT INTO notification_deliveries (notification_id, channel)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetUserNotifications :many
+-- Notificaciones de un usuario de la más reciente a la más antigua. El cursor
+-- es (sent_at, id) de la última notificación de la página anterior; en NULL
+-- se parte desde la más reciente.
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::bool OR read_at IS NULL)
+  AND (sqlc.narg(cursor_sent_at)::timestamp IS NULL
+       OR (sent_at, id) < (sqlc.narg(cursor_sent_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+ORDER BY sent_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :one
+-- Una notificación ya leída conserva su read_at
+UPDATE notifications
+SET read_at = COALESCE(read_at, now())
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: MarkAllNotificationsRead :many
+UPDATE notifications
+SET read_at = now()
+WHERE user_id = $1 AND read_at IS NULL
+RETURNING id;
+
 -- name: GetRoles :many
 SELECT * FROM roles;
 
@@ -111,9 +186,375 @@ SELECT * FROM dishes
 WHERE available_on = $1
 ORDER BY name;
 
+-- name: GetDishesByDateRange :many
+SELECT * FROM dishes
+WHERE available_on BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+ORDER BY available_on, name;
+
+-- Copia los platos de un rango de fechas desplazándolos offset_days días. Los
+-- platos que ya existen con el mismo nombre en la fecha destino se omiten,
+-- por lo que repetir la copia no duplica platos.
+-- name: CloneDishes :many
+INSERT INTO dishes (id, name, description, price, prep_time_minutes, available_on)
+SELECT gen_random_uuid(), d.name, d.description, d.price, d.prep_time_minutes, d.available_on + sqlc.arg(offset_days)::int
+FROM dishes d
+WHERE d.available_on BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+  AND NOT EXISTS (
+    SELECT 1 FROM dishes t
+    WHERE t.name = d.name AND t.available_on = d.available_on + sqlc.arg(offset_days)::int
+  )
+RETURNING *;
+
 -- name: UpdateOrderStatus :one
 UPDATE orders
 SET status = $2,
     updated_at = now()
 WHERE id = $1
-RETURNING id, user_id, dish_id, status, created_at, updated_at;
\ No newline at end of file
+RETURNING *;
+
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox (id, event_type, status, payload, created_at)
+VALUES ($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: GetPendingOutboxEvents :many
+SELECT * FROM outbox
+WHERE delivered_at IS NULL AND next_attempt_at <= now()
+ORDER BY created_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxEventDelivered :exec
+UPDATE outbox
+SET delivered_at = now(),
+    attempts = attempts + 1,
+    last_error = NULL
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET attempts = attempts + 1,
+    last_error = $2,
+    next_attempt_at = $3
+WHERE id = $1;
+
+-- name: GetUserPermissions :many
+SELECT DISTINCT p.name
+FROM permissions p
+JOIN role_permissions rp ON rp.permission_id = p.id
+JOIN user_roles ur ON ur.role_id = rp.role_id
+WHERE ur.user_id = $1
+ORDER BY p.name;
+
+-- name: AssignUserRole :execrows
+-- Asigna al usuario el rol con el nombre indicado. Retorna 0 filas si el rol
+-- no existe (los roles se crean en seed.sql).
+INSERT INTO user_roles (user_id, role_id)
+SELECT sqlc.arg(user_id), r.id
+FROM roles r
+WHERE r.name = sqlc.arg(role_name)
+ON CONFLICT DO NOTHING;
+
+-- name: GetUserRoleNames :many
+SELECT r.name
+FROM roles r
+JOIN user_roles ur ON ur.role_id = r.id
+WHERE ur.user_id = $1
+ORDER BY r.name;
+
+-- name: GetOrderForUpdate :one
+SELECT * FROM orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ReserveIdempotencyKey :execrows
+-- Reserva la llave para un request nuevo. Si la llave existe y no ha expirado
+-- no modifica nada y retorna 0 filas; una llave expirada se reutiliza. Una
+-- reserva sin respuesta (status_code NULL) de hace más de lease_seconds se
+-- considera abandonada, por ejemplo porque el writer se cayó, y también se
+-- reutiliza.
+INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at)
+VALUES (
+    sqlc.arg(user_id),
+    sqlc.arg(key),
+    sqlc.arg(fingerprint),
+    now() + make_interval(secs => sqlc.arg(ttl_seconds)::float8)
+)
+ON CONFLICT (user_id, key) DO UPDATE
+SET fingerprint = EXCLUDED.fingerprint,
+    status_code = NULL,
+    response_body = NULL,
+    response_headers = NULL,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < now()
+   OR (idempotency_keys.status_code IS NULL
+       AND idempotency_keys.created_at < now() - make_interval(secs => sqlc.arg(lease_seconds)::float8));
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $3,
+    response_body = $4,
+    response_headers = $5
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < now();
+
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (id, url, event_types, secret)
+VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+ORDER BY created_at, id;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET url = $2,
+    event_types = $3,
+    active = $4,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: ListWebhookSubscriptionsForEvent :many
+-- Suscripciones activas que escuchan el tipo de evento
+SELECT * FROM webhook_subscriptions
+WHERE active AND sqlc.arg(event_type)::text = ANY(event_types);
+
+-- name: CreateWebhookDelivery :execrows
+-- Un evento reentregado por el EventBus no genera una segunda entrega
+INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+-- name: ClaimWebhookDeliveries :many
+-- Toma un lote de entregas pendientes de suscripciones activas y posterga su
+-- siguiente intento por lease_seconds, de modo que otra instancia del worker
+-- no las envíe mientras están en curso.
+UPDATE webhook_deliveries d
+SET next_attempt_at = now() + make_interval(secs => sqlc.arg(lease_seconds)::float8)
+FROM webhook_subscriptions s
+WHERE s.id = d.subscription_id
+  AND d.id IN (
+    SELECT pd.id FROM webhook_deliveries pd
+    JOIN webhook_subscriptions ps ON ps.id = pd.subscription_id
+    WHERE pd.status = 'pending' AND pd.next_attempt_at <= now() AND ps.active
+    ORDER BY pd.next_attempt_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE OF pd SKIP LOCKED
+  )
+RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.attempts, s.url, s.secret;
+
+-- name: CreateWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (delivery_id, status_code, error, duration_ms)
+VALUES ($1, $2, $3, $4);
+
+-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered',
+    attempts = attempts + 1,
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = now()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- status queda en 'pending' para reintentar en next_attempt_at o pasa a 'dead'
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    last_status_code = $3,
+    last_error = $4,
+    next_attempt_at = $5
+WHERE id = $1;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+-- Entregas de la más reciente a la más antigua, opcionalmente de una
+-- suscripción y con un estado. El cursor es (created_at, id) de la última
+-- entrega de la página anterior.
+SELECT * FROM webhook_deliveries
+WHERE (sqlc.narg(subscription_id)::uuid IS NULL OR subscription_id = sqlc.narg(subscription_id))
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg(cursor_created_at)::timestamp, sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListWebhookDeliveryAttempts :many
+SELECT * FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY attempted_at, id;
+
+-- name: RedeliverWebhookDelivery :one
+-- Vuelve a encolar una entrega con todos sus intentos disponibles
+UPDATE webhook_deliveries
+SET status = 'pending',
+    attempts = 0,
+    next_attempt_at = now(),
+    delivered_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: AppendEvent :execrows
+-- Un evento reentregado por el EventBus o republicado no se duplica
+INSERT INTO events (id, event_type, status, aggregate_type, aggregate_id, payload, occurred_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: ListEvents :many
+-- Historial de eventos en orden cronológico. type_patterns son patrones LIKE
+-- del tipo de evento; los filtros en NULL no se aplican. El cursor es
+-- (occurred_at, position) del último evento de la página anterior.
+SELECT * FROM events
+WHERE (sqlc.narg(type_patterns)::text[] IS NULL OR event_type LIKE ANY(sqlc.narg(type_patterns)::text[]))
+  AND (sqlc.narg(aggregate_id)::uuid IS NULL OR aggregate_id = sqlc.narg(aggregate_id))
+  AND (sqlc.narg(occurred_from)::timestamp IS NULL OR occurred_at >= sqlc.narg(occurred_from))
+  AND (sqlc.narg(occurred_to)::timestamp IS NULL OR occurred_at < sqlc.narg(occurred_to))
+  AND (sqlc.narg(cursor_occurred_at)::timestamp IS NULL
+       OR (occurred_at, position) > (sqlc.narg(cursor_occurred_at)::timestamp, sqlc.narg(cursor_position)::bigint))
+ORDER BY occurred_at, position
+LIMIT sqlc.arg(row_limit);
+
+-- name: EnsureProjectionCheckpoint :exec
+INSERT INTO projection_checkpoints (name)
+VALUES ($1)
+ON CONFLICT DO NOTHING;
+
+-- name: LockProjectionCheckpoint :one
+-- Bloquea el checkpoint hasta el fin de la transacción, de modo que dos
+-- instancias no apliquen a la vez los mismos eventos
+SELECT transaction_id, position FROM projection_checkpoints
+WHERE name = $1
+FOR UPDATE;
+
+-- name: SaveProjectionCheckpoint :exec
+UPDATE projection_checkpoints
+SET transaction_id = $2,
+    position = $3,
+    updated_at = now()
+WHERE name = $1;
+
+-- name: ListEventsAfter :many
+-- Eventos del historial posteriores a un checkpoint (transaction_id,
+-- position), en ese orden. Sólo se leen los eventos de transacciones
+-- anteriores a la más antigua todavía activa: esas transacciones ya
+-- terminaron, por lo que ningún evento puede aparecer después antes del
+-- checkpoint. type_patterns son patrones LIKE del tipo de evento.
+SELECT * FROM events
+WHERE (transaction_id, position) > (sqlc.arg(after_transaction_id)::bigint, sqlc.arg(after_position)::bigint)
+  AND transaction_id < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+  AND event_type LIKE ANY(sqlc.arg(type_patterns)::text[])
+ORDER BY transaction_id, position
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpsertMenuDish :exec
+INSERT INTO menu_by_date (dish_id, available_on, name, description, price, prep_time_minutes, version)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (dish_id) DO UPDATE
+SET available_on = EXCLUDED.available_on,
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    price = EXCLUDED.price,
+    prep_time_minutes = EXCLUDED.prep_time_minutes,
+    version = EXCLUDED.version
+WHERE menu_by_date.version <= EXCLUDED.version;
+
+-- name: DeleteMenuDish :exec
+DELETE FROM menu_by_date
+WHERE dish_id = $1;
+
+-- name: DeleteAllMenuDishes :exec
+DELETE FROM menu_by_date;
+
+-- name: SeedMenuByDate :exec
+-- Carga menu_by_date desde la tabla dishes, igual que la migración que la
+-- creó. version es updated_at, por lo que los eventos anteriores al estado
+-- actual del plato no lo sobrescriben.
+INSERT INTO menu_by_date (dish_id, available_on, name, description, price, prep_time_minutes, version)
+SELECT id, available_on, name, COALESCE(description, ''), price, prep_time_minutes, COALESCE(updated_at, now())
+FROM dishes
+ON CONFLICT DO NOTHING;
+
+-- name: GetMenuByDate :many
+SELECT * FROM menu_by_date
+WHERE available_on = $1
+ORDER BY name;
+
+-- name: UpsertUserOrderHistory :exec
+INSERT INTO user_order_history (order_id, user_id, status, total, items, created_at, updated_at, version)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (order_id) DO UPDATE
+SET user_id = EXCLUDED.user_id,
+    status = EXCLUDED.status,
+    total = EXCLUDED.total,
+    items = EXCLUDED.items,
+    updated_at = EXCLUDED.updated_at,
+    version = EXCLUDED.version
+WHERE user_order_history.version <= EXCLUDED.version;
+
+-- name: DeleteUserOrderHistory :exec
+DELETE FROM user_order_history
+WHERE order_id = $1;
+
+-- name: DeleteAllUserOrderHistory :exec
+DELETE FROM user_order_history;
+
+-- name: SeedUserOrderHistory :exec
+-- Carga user_order_history desde orders y order_items, igual que la migración
+-- que la creó. version es updated_at, por lo que los eventos anteriores al
+-- estado actual de la orden no la sobrescriben.
+INSERT INTO user_order_history (order_id, user_id, status, total, items, created_at, updated_at, version)
+SELECT
+    o.id,
+    o.user_id,
+    o.status,
+    COALESCE(SUM(oi.unit_price * oi.quantity), 0),
+    COALESCE(
+        jsonb_agg(jsonb_build_object(
+            'id', oi.id,
+            'dish_id', oi.dish_id,
+            'dish_name', d.name,
+            'dish_description', COALESCE(d.description, ''),
+            'quantity', oi.quantity,
+            'unit_price', oi.unit_price::text,
+            'subtotal', (oi.unit_price * oi.quantity)::text,
+            'notes', COALESCE(oi.notes, ''),
+            'prep_time_minutes', d.prep_time_minutes
+        ) ORDER BY oi.created_at, oi.id) FILTER (WHERE oi.id IS NOT NULL),
+        '[]'::jsonb
+    ),
+    COALESCE(o.created_at, now()),
+    COALESCE(o.updated_at, now()),
+    COALESCE(o.updated_at, now())
+FROM orders o
+LEFT JOIN order_items oi ON oi.order_id = o.id
+LEFT JOIN dishes d ON d.id = oi.dish_id
+GROUP BY o.id
+ON CONFLICT DO NOTHING;
+
+-- name: GetUserOrderHistory :many
+SELECT * FROM user_order_history
+WHERE user_id = $1
+ORDER BY created_at DESC;
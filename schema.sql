@@ -1,73 +0,0 @@
-CREATE TABLE users (
-    id UUID PRIMARY KEY,
-    name TEXT NOT NULL,
-    email TEXT UNIQUE NOT NULL,
-    created_at TIMESTAMP DEFAULT now()
-);
-
-CREATE TABLE dishes (
-    id UUID PRIMARY KEY,
-    name TEXT NOT NULL,
-    description TEXT,
-    price NUMERIC(10, 2) NOT NULL,
-    prep_time_minutes INT NOT NULL, -- tiempo estimado de preparación
-    available_on DATE NOT NULL, -- fecha en la que estará disponible
-    created_at TIMESTAMP DEFAULT now(),
-    updated_at TIMESTAMP DEFAULT now()
-);
-
-CREATE TABLE orders (
-    id UUID PRIMARY KEY,
-    user_id UUID NOT NULL REFERENCES users(id),
-    dish_id UUID NOT NULL REFERENCES dishes(id),
-    status TEXT NOT NULL CHECK (status IN ('received', 'confirmed', 'preparing', 'served', 'cancelled')),
-    created_at TIMESTAMP DEFAULT now(),
-    updated_at TIMESTAMP DEFAULT now()
-);
-
--- Índice único parcial para asegurar que un usuario no tenga más de una orden activa
-CREATE UNIQUE INDEX idx_orders_active_user ON orders (user_id)
-WHERE status NOT IN ('served', 'cancelled');
-
-CREATE TABLE notifications (
-    id UUID PRIMARY KEY,
-    user_id UUID NOT NULL REFERENCES users(id),
-    order_id UUID NOT NULL REFERENCES orders(id),
-    message TEXT NOT NULL,
-    sent_at TIMESTAMP DEFAULT now()
-);
-
-CREATE TABLE roles (
-    id UUID PRIMARY KEY,
-    name TEXT UNIQUE NOT NULL CHECK (name IN ('client', 'kitchen', 'admin'))
-);
-
-CREATE TABLE user_roles (
-    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
-    PRIMARY KEY (user_id, role_id)
-);
-
-CREATE TABLE permissions (
-    id UUID PRIMARY KEY,
-    name TEXT UNIQUE NOT NULL
-);
-
-CREATE TABLE role_permissions (
-    role_id UUID NOT NULL REFERENCES roles(id),
-    permission_id UUID NOT NULL REFERENCES permissions(id),
-    PRIMARY KEY (role_id, permission_id)
-);
-
-CREATE TABLE IF NOT EXISTS dishes (
-    id UUID PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    description TEXT,
-    price NUMERIC(10,2) NOT NULL,
-    prep_time_minutes INTEGER NOT NULL,
-    available_on DATE NOT NULL,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
-
-ALTER TABLE dishes ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
\ No newline at end of file